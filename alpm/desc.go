@@ -0,0 +1,198 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package alpm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reason of package installation.
+type Reason int
+
+const (
+	// Package was explicitly requested by user.
+	ReasonExplicit Reason = 0
+	// Package was installed as dependency of other package.
+	ReasonDepend Reason = 1
+)
+
+func (r Reason) String() string {
+	if r == ReasonDepend {
+		return "dependency"
+	}
+	return "explicit"
+}
+
+// Backup file of package with MD5 sum of original content.
+type Backup struct {
+	Path string
+	MD5  string
+}
+
+// Package entry from pacman database. Local database entries have install
+// date, reason, files and backup, sync database entries have file name,
+// sizes and checksums of package archive.
+type Package struct {
+	Name         string
+	Base         string
+	Version      string
+	Description  string
+	URL          string
+	Arch         string
+	BuildDate    time.Time
+	InstallDate  time.Time
+	Packager     string
+	Reason       Reason
+	Licenses     []string
+	Groups       []string
+	Validation   []string
+	Replaces     []string
+	Depends      []string
+	OptDepends   []string
+	MakeDepends  []string
+	CheckDepends []string
+	Conflicts    []string
+	Provides     []string
+	// Installed size in bytes.
+	Size int64
+	// Files owned by package, relative to root without leading slash.
+	Files  []string
+	Backup []Backup
+	// Local package has install scriptlet.
+	Scriptlet bool
+
+	Filename       string
+	CompressedSize int64
+	MD5Sum         string
+	SHA256Sum      string
+	PGPSig         string
+}
+
+// Parse database entry in desc format: each field starts with %NAME% line,
+// followed by value lines and terminated with empty line. Fields are added
+// to provided package, so desc and files entries can be merged.
+func parseDesc(r io.Reader, pkg *Package) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+
+	var field string
+	var values []string
+	for {
+		more := scanner.Scan()
+		line := scanner.Text()
+		if !more || line == `` {
+			if field != `` {
+				err := setField(pkg, field, values)
+				if err != nil {
+					return err
+				}
+			}
+			field, values = ``, nil
+			if !more {
+				return scanner.Err()
+			}
+			continue
+		}
+		if field == `` {
+			if !strings.HasPrefix(line, "%") || !strings.HasSuffix(line, "%") || len(line) < 3 {
+				return fmt.Errorf("expected field name, got: %s", line)
+			}
+			field = strings.Trim(line, "%")
+			continue
+		}
+		values = append(values, line)
+	}
+}
+
+// Set package field from database values, unknown fields are ignored.
+func setField(pkg *Package, field string, values []string) error {
+	var single string
+	if len(values) > 0 {
+		single = values[0]
+	}
+
+	var err error
+	switch field {
+	case "NAME":
+		pkg.Name = single
+	case "BASE":
+		pkg.Base = single
+	case "VERSION":
+		pkg.Version = single
+	case "DESC":
+		pkg.Description = single
+	case "URL":
+		pkg.URL = single
+	case "ARCH":
+		pkg.Arch = single
+	case "BUILDDATE":
+		pkg.BuildDate, err = parseUnix(single)
+	case "INSTALLDATE":
+		pkg.InstallDate, err = parseUnix(single)
+	case "PACKAGER":
+		pkg.Packager = single
+	case "REASON":
+		var n int
+		n, err = strconv.Atoi(single)
+		pkg.Reason = Reason(n)
+	case "SIZE", "ISIZE":
+		pkg.Size, err = strconv.ParseInt(single, 10, 64)
+	case "CSIZE":
+		pkg.CompressedSize, err = strconv.ParseInt(single, 10, 64)
+	case "LICENSE":
+		pkg.Licenses = values
+	case "GROUPS":
+		pkg.Groups = values
+	case "VALIDATION":
+		pkg.Validation = values
+	case "REPLACES":
+		pkg.Replaces = values
+	case "DEPENDS":
+		pkg.Depends = values
+	case "OPTDEPENDS":
+		pkg.OptDepends = values
+	case "MAKEDEPENDS":
+		pkg.MakeDepends = values
+	case "CHECKDEPENDS":
+		pkg.CheckDepends = values
+	case "CONFLICTS":
+		pkg.Conflicts = values
+	case "PROVIDES":
+		pkg.Provides = values
+	case "FILES":
+		pkg.Files = values
+	case "BACKUP":
+		for _, value := range values {
+			path, md5, _ := strings.Cut(value, "\t")
+			pkg.Backup = append(pkg.Backup, Backup{Path: path, MD5: md5})
+		}
+	case "FILENAME":
+		pkg.Filename = single
+	case "MD5SUM":
+		pkg.MD5Sum = single
+	case "SHA256SUM":
+		pkg.SHA256Sum = single
+	case "PGPSIG":
+		pkg.PGPSig = single
+	}
+	if err != nil {
+		return fmt.Errorf("not valid %%%s%% value: %s", field, single)
+	}
+	return nil
+}
+
+// Parse unix timestamp from database.
+func parseUnix(value string) (time.Time, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(n, 0), nil
+}
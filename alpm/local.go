@@ -0,0 +1,69 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package alpm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Default location of pacman databases.
+const DefaultDBPath = "/var/lib/pacman"
+
+// Read all packages from local database in dbpath, package files and backup
+// entries are read only if files is set.
+func ReadLocal(dbpath string, files bool) ([]Package, error) {
+	entries, err := os.ReadDir(filepath.Join(dbpath, "local"))
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []Package
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		pkg, err := readLocalEntry(filepath.Join(dbpath, "local", entry.Name()), files)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, *pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name
+	})
+	return pkgs, nil
+}
+
+// Read desc and optionally files of local database entry.
+func readLocalEntry(dir string, files bool) (*Package, error) {
+	var pkg Package
+	names := []string{"desc"}
+	if files {
+		names = append(names, "files")
+	}
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) && name == "files" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = parseDesc(f, &pkg)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, name), err)
+		}
+	}
+	if pkg.Name == `` || pkg.Version == `` {
+		return nil, fmt.Errorf("%s: entry without name or version", dir)
+	}
+	_, err := os.Stat(filepath.Join(dir, "install"))
+	pkg.Scriptlet = err == nil
+	return &pkg, nil
+}
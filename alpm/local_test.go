@@ -0,0 +1,89 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package alpm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const nanoDesc = `%NAME%
+nano
+
+%VERSION%
+7.2-1
+
+%DESC%
+Pico editor clone with enhancements
+
+%ARCH%
+x86_64
+
+%BUILDDATE%
+1705140000
+
+%INSTALLDATE%
+1705150000
+
+%SIZE%
+2621440
+
+%REASON%
+1
+
+%LICENSE%
+GPL
+
+%DEPENDS%
+glibc
+ncurses
+zlib
+
+`
+
+const nanoFiles = `%FILES%
+etc/
+etc/nanorc
+usr/bin/nano
+
+%BACKUP%
+etc/nanorc	d41d8cd98f00b204e9800998ecf8427e
+
+`
+
+func TestReadLocal(t *testing.T) {
+	dbpath := t.TempDir()
+	entry := filepath.Join(dbpath, "local", "nano-7.2-1")
+	assert.NoError(t, os.MkdirAll(entry, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dbpath, "local", "ALPM_DB_VERSION"), []byte("9\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(entry, "desc"), []byte(nanoDesc), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(entry, "files"), []byte(nanoFiles), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(entry, "install"), []byte("post_install() {}\n"), 0o644))
+
+	pkgs, err := ReadLocal(dbpath, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pkgs))
+	pkg := pkgs[0]
+	assert.Equal(t, "nano", pkg.Name)
+	assert.Equal(t, "7.2-1", pkg.Version)
+	assert.Equal(t, ReasonDepend, pkg.Reason)
+	assert.Equal(t, int64(2621440), pkg.Size)
+	assert.Equal(t, []string{"glibc", "ncurses", "zlib"}, pkg.Depends)
+	assert.Equal(t, int64(1705140000), pkg.BuildDate.Unix())
+	assert.True(t, pkg.Scriptlet)
+	assert.Zero(t, pkg.Files)
+
+	pkgs, err = ReadLocal(dbpath, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"etc/", "etc/nanorc", "usr/bin/nano"}, pkgs[0].Files)
+	assert.Equal(t, []Backup{{Path: "etc/nanorc", MD5: "d41d8cd98f00b204e9800998ecf8427e"}}, pkgs[0].Backup)
+
+	_, err = ReadLocal(t.TempDir(), false)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,79 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package alpm
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+
+	"ion.lc/core/tab/pacman"
+)
+
+// Read packages from sync database archive (.db or .files), compressed with
+// gzip, zstd or uncompressed. Files are filled for .files databases.
+func ReadSync(r io.Reader) ([]Package, error) {
+	dr, err := pacman.Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+
+	entries := map[string]*Package{}
+	tr := tar.NewReader(dr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Entries are stored as name-pkgver-pkgrel/{desc,files,depends}.
+		dir, file := path.Split(hdr.Name)
+		dir = path.Clean(dir)
+		if dir == "." || (file != "desc" && file != "files" && file != "depends") {
+			continue
+		}
+		pkg, ok := entries[dir]
+		if !ok {
+			pkg = &Package{}
+			entries[dir] = pkg
+		}
+		err = parseDesc(tr, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+	}
+
+	var pkgs []Package
+	for dir, pkg := range entries {
+		if pkg.Name == `` || pkg.Version == `` {
+			return nil, fmt.Errorf("%s: entry without name or version", dir)
+		}
+		pkgs = append(pkgs, *pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name
+	})
+	return pkgs, nil
+}
+
+// Read packages from sync database archive file.
+func ReadSyncFile(file string) ([]Package, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSync(f)
+}
@@ -0,0 +1,62 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package alpm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"ion.lc/core/tab/internal/testarchive"
+)
+
+const nanoSyncDesc = `%FILENAME%
+nano-7.2-1-x86_64.pkg.tar.zst
+
+%NAME%
+nano
+
+%VERSION%
+7.2-1
+
+%CSIZE%
+611434
+
+%ISIZE%
+2621440
+
+%SHA256SUM%
+e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+
+%DEPENDS%
+glibc
+ncurses
+
+`
+
+func TestReadSync(t *testing.T) {
+	for name, compression := range map[string]testarchive.Compression{
+		"gzip": testarchive.Gzip,
+		"zstd": testarchive.Zstd,
+	} {
+		db := testarchive.Build(t, compression,
+			testarchive.Entry{Name: "nano-7.2-1/", Mode: 0o755},
+			testarchive.Entry{Name: "nano-7.2-1/desc", Mode: 0o644, Data: nanoSyncDesc},
+			testarchive.Entry{Name: "nano-7.2-1/files", Mode: 0o644, Data: testarchive.Desc("FILES", "usr/bin/nano")},
+		)
+		pkgs, err := ReadSync(bytes.NewReader(db))
+		assert.NoError(t, err, name)
+		assert.Equal(t, 1, len(pkgs), name)
+		pkg := pkgs[0]
+		assert.Equal(t, "nano", pkg.Name, name)
+		assert.Equal(t, "7.2-1", pkg.Version, name)
+		assert.Equal(t, "nano-7.2-1-x86_64.pkg.tar.zst", pkg.Filename, name)
+		assert.Equal(t, int64(611434), pkg.CompressedSize, name)
+		assert.Equal(t, int64(2621440), pkg.Size, name)
+		assert.Equal(t, []string{"glibc", "ncurses"}, pkg.Depends, name)
+		assert.Equal(t, []string{"usr/bin/nano"}, pkg.Files, name)
+	}
+}
@@ -6,8 +6,8 @@ package creds
 
 import (
 	"errors"
-	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path"
 	"strings"
@@ -33,34 +33,54 @@ func Get(protocol, addr string) (string, string, error) {
 
 	lines := strings.Split(string(b), "\n")
 	for _, line := range lines {
-		if strings.HasPrefix(line, protocol+":") && strings.HasSuffix(line, addr) {
-			splt := strings.Split(line, ":")
-
-			login := strings.ReplaceAll(splt[1], "//", "")
-			password := strings.Split(splt[2], "@")[0]
-
-			return login, strings.ReplaceAll(password, "%40", "@"), nil
+		u := parseLine(line, protocol, addr)
+		if u == nil || u.User == nil {
+			continue
 		}
+		password, _ := u.User.Password()
+		return u.User.Username(), password, nil
 	}
 
 	return ``, ``, ErrNoCreds
 }
 
+// Parse credentials line, nil is returned if line is not valid or does not
+// belong to provided protocol and address. Host is compared exactly, so
+// credentials of one host never match other host with the same suffix.
+func parseLine(line, protocol, addr string) *url.URL {
+	u, err := url.Parse(strings.TrimSpace(line))
+	if err != nil || u.Scheme != protocol || u.Host != addr {
+		return nil
+	}
+	return u
+}
+
 // Save/Update login and password in git credentials file.
 func Put(protocol, addr, login, pass string) error {
 	userdir, err := os.UserHomeDir()
 	if err != nil {
 		return err
 	}
-	pass = strings.ReplaceAll(pass, "@", "%40")
-
+	// Login and password are escaped by url package, so they are read back
+	// unchanged with parseLine.
 	credsfile := path.Join(userdir, ".git-credentials")
-	credsline := fmt.Sprintf("%s://%s:%s@%s\n", protocol, login, pass, addr)
+	credsurl := &url.URL{Scheme: protocol, User: url.UserPassword(login, pass), Host: addr}
+	credsline := credsurl.String() + "\n"
 
 	b, err := os.ReadFile(credsfile)
 	if errors.Is(err, os.ErrNotExist) || err == nil {
-		newcontents := append(b, []byte(credsline)...)
-		return os.WriteFile(credsfile, newcontents, os.ModePerm)
+		var newcontents string
+		for _, line := range strings.Split(string(b), "\n") {
+			if line == `` {
+				continue
+			}
+			if parseLine(line, protocol, addr) != nil {
+				continue
+			}
+			newcontents += line + "\n"
+		}
+		newcontents += credsline
+		return os.WriteFile(credsfile, []byte(newcontents), os.ModePerm)
 	}
 	return err
 }
@@ -1,12 +1,14 @@
 module ion.lc/core/tab
 
-go 1.20
+go 1.22
 
 require (
 	github.com/alecthomas/assert/v2 v2.4.0
 	github.com/fatih/color v1.16.0
 	github.com/jessevdk/go-flags v1.5.0
+	github.com/klauspost/compress v1.17.4
 	github.com/mitchellh/ioprogress v0.0.0-20180201004757-6a23b12fa88e
+	golang.org/x/crypto v0.15.0
 	golang.org/x/term v0.14.0
 )
 
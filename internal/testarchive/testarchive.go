@@ -0,0 +1,85 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+// Package testarchive builds tar archives of packages and sync databases
+// for tests.
+package testarchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Entry of archive, names ending with slash are written as directories.
+type Entry struct {
+	Name string
+	Mode int64
+	Data string
+}
+
+// Compression of archive.
+type Compression int
+
+const (
+	Zstd Compression = iota
+	Gzip
+)
+
+// Build compressed tar archive with provided entries.
+func Build(t testing.TB, c Compression, entries ...Entry) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	var cw io.WriteCloser
+	switch c {
+	case Gzip:
+		cw = gzip.NewWriter(&b)
+	default:
+		zw, err := zstd.NewWriter(&b)
+		assert.NoError(t, err)
+		cw = zw
+	}
+	tw := tar.NewWriter(cw)
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.Name,
+			Mode:     e.Mode,
+			Size:     int64(len(e.Data)),
+			Typeflag: tar.TypeReg,
+		}
+		if strings.HasSuffix(e.Name, "/") {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Size = 0
+		}
+		assert.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.Data))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, cw.Close())
+	return b.Bytes()
+}
+
+// Write compressed tar archive with provided entries to file.
+func WriteFile(t testing.TB, path string, c Compression, entries ...Entry) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(path, Build(t, c, entries...), 0o644))
+}
+
+// Build desc file of pacman database from key and value pairs, values of list
+// fields are separated with newlines.
+func Desc(fields ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(fields); i += 2 {
+		b.WriteString("%" + fields[i] + "%\n" + fields[i+1] + "\n\n")
+	}
+	return b.String()
+}
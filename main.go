@@ -5,6 +5,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -24,6 +25,11 @@ var opts struct {
 	Sync   bool `short:"S" long:"sync"`
 	Push   bool `short:"P" long:"push"`
 	Build  bool `short:"B" long:"build"`
+	Serve  bool `long:"serve"`
+	Mirror bool `long:"mirror"`
+
+	History  bool `long:"history"`
+	Rollback bool `long:"rollback"`
 }
 
 var help = `Decentralized package manager
@@ -34,6 +40,10 @@ operations:
 	tab {-R --remove} [options] [(registry)/(owner)/package(s)]
 	tab {-B --build}  [options] [git/repository(s)]
 	tab {-Q --query}  [options] [package(s)]
+	tab --serve       [options]
+	tab --mirror      [options] <src-registry/owner> <dst-registry/owner> [package(s)]
+	tab --history     [options]
+	tab --rollback    [options] <transaction-id>
 
 use 'tab {-h --help}' with an operation for available options`
 
@@ -50,7 +60,10 @@ func main() {
 	err := run()
 	if err != nil {
 		if !strings.Contains(err.Error(), "exit status 1") {
-			fmt.Println(msgs.Err + err.Error())
+			fmt.Fprintln(os.Stderr, msgs.Err+err.Error())
+		}
+		if errors.Is(err, tab.ErrPartialFailure) {
+			os.Exit(2)
 		}
 		os.Exit(1)
 	}
@@ -70,35 +83,63 @@ func run() error {
 		return nil
 
 	case opts.Sync:
-		return tab.Sync(args())
+		return tab.Sync(args(tab.SyncParameters{}))
 
 	case opts.Push && opts.Help:
 		fmt.Println(tab.PushHelp)
 		return nil
 
 	case opts.Push:
-		return tab.Push(args())
+		return tab.Push(args(tab.PushParameters{}))
 
 	case opts.Remove && opts.Help:
 		fmt.Println(tab.RemoveHelp)
 		return nil
 
 	case opts.Remove:
-		return tab.Remove(args())
+		return tab.Remove(args(tab.RemoveParameters{}))
 
 	case opts.Query && opts.Help:
 		fmt.Println(tab.QueryHelp)
 		return nil
 
 	case opts.Query:
-		return tab.Query(args())
+		return tab.Query(args(tab.QueryParameters{}))
 
 	case opts.Build && opts.Help:
 		fmt.Println(tab.BuildHelp)
 		return nil
 
 	case opts.Build:
-		return tab.Build(args())
+		return tab.Build(args(tab.BuildParameters{}))
+
+	case opts.Serve && opts.Help:
+		fmt.Println(tab.ServeHelp)
+		return nil
+
+	case opts.Serve:
+		return tab.Serve(args(tab.ServeParameters{}))
+
+	case opts.Mirror && opts.Help:
+		fmt.Println(tab.MirrorHelp)
+		return nil
+
+	case opts.Mirror:
+		return tab.Mirror(args(tab.MirrorParameters{}))
+
+	case opts.History && opts.Help:
+		fmt.Println(tab.HistoryHelp)
+		return nil
+
+	case opts.History:
+		return tab.History(args(tab.HistoryParameters{}))
+
+	case opts.Rollback && opts.Help:
+		fmt.Println(tab.RollbackHelp)
+		return nil
+
+	case opts.Rollback:
+		return tab.Rollback(args(tab.RollbackParameters{}))
 
 	case opts.Version:
 		fmt.Println(version)
@@ -114,20 +155,22 @@ func run() error {
 }
 
 // Function to get list of command line arguements. It automatically filters
-// all string CLI parameters with reflect.
-func args() []string {
+// all string and int CLI parameters of root options and provided operation
+// parameters with reflect.
+func args(prms any) []string {
 	var arglist []string
 
-	v := reflect.ValueOf(opts)
-
-	for i := 0; i < v.NumField(); i++ {
-		if v.Field(i).Type().String() == "string" {
-			short := reflect.TypeOf(opts).Field(i).Tag.Get("short")
-			if short != "" {
-				arglist = append(arglist, "-"+short)
+	for _, v := range []reflect.Value{reflect.ValueOf(opts), reflect.ValueOf(prms)} {
+		for i := 0; i < v.NumField(); i++ {
+			kind := v.Field(i).Type().String()
+			if kind == "string" || kind == "[]string" || kind == "int" {
+				short := v.Type().Field(i).Tag.Get("short")
+				if short != "" {
+					arglist = append(arglist, "-"+short)
+				}
+				long := v.Type().Field(i).Tag.Get("long")
+				arglist = append(arglist, "--"+long)
 			}
-			long := reflect.TypeOf(opts).Field(i).Tag.Get("long")
-			arglist = append(arglist, "--"+long)
 		}
 	}
 
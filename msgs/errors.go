@@ -51,3 +51,41 @@ Git signing key: ` + color.HiCyanString("%s") + `
 You can check your gpg identity with command: ` + color.BlueString("gpg -K") + `
 
 Make sure, that ` + color.HiRedString("user.signingkey") + ` matches key in ` + color.CyanString("~/.gitconfig")
+
+var ErrNoSignature = `package signature not found: ` + color.HiCyanString("%s") + `
+
+Sign package before pushing it, or push with ` + color.BlueString("--sign") + ` flag to create
+signature using your GnuPG authority.`
+
+var ErrSignerPackagerMissmatch = `package packager and signer identities are different.
+
+Package: ` + color.HiCyanString("%s") + `
+Package packager: ` + color.HiCyanString("%s") + `
+Signing authority: ` + color.HiGreenString("%s") + `
+
+Sign package with key matching ` + color.BlueString("packager") + ` field in package, you can choose key with ` + color.BlueString("--key") + ` flag.`
+
+var ErrRegistryUnauthorized = `Registry rejected provided credentials.
+
+Check login and password for registry, credentials are stored in ` + color.CyanString("~/.git-credentials") + `.
+If registry requires tokens, use access token with package write scope as password.`
+
+var ErrRegistryVersionExists = `Package version is already published in registry.
+
+Bump ` + color.BlueString("pkgrel") + ` or ` + color.BlueString("pkgver") + ` in PKGBUILD, or push with ` + color.BlueString("--replace") + ` to overwrite
+existing version or ` + color.BlueString("--skip-existing") + ` to ignore it.`
+
+var ErrRegistryInvalidSignature = `Registry was not able to verify package signature.
+
+Make sure package is signed with key, that is added to your registry account.
+You can upload your key with: ` + color.YellowString("tab -Pe --upload <registry>")
+
+var ErrRegistryPackageNotFound = `Package or package version does not exist in registry.
+
+Check registry, owner, package name and version, versions should be provided
+with release, for example: ` + color.YellowString("example.com/owner/package@1-1")
+
+var ErrRegistryQuotaExceeded = `Registry storage quota is exceeded.
+
+Remove old package versions from registry, for example with:
+` + color.YellowString("tab -R <registry>/<owner>/<package>@<ver-rel>")
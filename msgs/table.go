@@ -0,0 +1,41 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package msgs
+
+import (
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Write aligned table with bold header to provided io.Writer.
+func Table(w io.Writer, header []string, rows [][]string) {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	format := func(row []string) string {
+		var cells []string
+		for i, cell := range row {
+			if i == len(row)-1 {
+				cells = append(cells, cell)
+				continue
+			}
+			cells = append(cells, cell+strings.Repeat(" ", widths[i]-len(cell)))
+		}
+		return strings.Join(cells, "  ")
+	}
+
+	w.Write([]byte(color.New(color.Bold).Sprint(format(header)) + "\n"))
+	for _, row := range rows {
+		w.Write([]byte(format(row) + "\n"))
+	}
+}
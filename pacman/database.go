@@ -0,0 +1,61 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"io"
+	"os"
+
+	"ion.lc/core/tab/process"
+)
+
+// Options to apply when changing installed package entries.
+type DatabaseParameters struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// Run with sudo priveleges. [sudo]
+	Sudo bool
+	// Mark packages as non-explicitly installed. [--asdeps]
+	AsDeps bool
+	// Mark packages as explicitly installed. [--asexplicit]
+	AsExplicit bool
+}
+
+func DatabaseDefault() *DatabaseParameters {
+	return &DatabaseParameters{
+		Sudo:   true,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
+	}
+}
+
+// Change install reason of installed packages.
+func DatabaseList(pkgs []string, opts ...DatabaseParameters) error {
+	p := formOptions(opts, DatabaseDefault)
+
+	args := []string{"-D"}
+	if p.AsDeps {
+		args = append(args, "--asdeps")
+	}
+	if p.AsExplicit {
+		args = append(args, "--asexplicit")
+	}
+	args = append(args, pkgs...)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	return process.Command(&process.Params{
+		Stdout:  p.Stdout,
+		Stderr:  p.Stderr,
+		Stdin:   p.Stdin,
+		Sudo:    p.Sudo,
+		Command: pacman,
+		Args:    args,
+	}).Run()
+}
@@ -0,0 +1,177 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Get info about installed packages with `pacman -Qi`.
+func InfoList(pkgs []string) ([]PackageInfoFull, error) {
+	return info("-Qi", pkgs)
+}
+
+// Get info about packages in sync databases with `pacman -Si`.
+func SyncInfo(pkgs []string) ([]PackageInfoFull, error) {
+	return info("-Si", pkgs)
+}
+
+// Run pacman info query and parse its output.
+func info(flag string, pkgs []string) ([]PackageInfoFull, error) {
+	if len(pkgs) == 0 {
+		return nil, errors.New("no packages provided to get info")
+	}
+	out, err := output(append([]string{flag}, pkgs...)...)
+	if err != nil {
+		return nil, errors.New("unable to get info: " + err.Error())
+	}
+	return ParseInfo(out)
+}
+
+// Run pacman in C locale, so output can be parsed, and return its stdout.
+// Error contains pacman stderr.
+func output(args ...string) (string, error) {
+	var b bytes.Buffer
+	var e bytes.Buffer
+	cmd := exec.Command(pacman, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	cmd.Stdout = &b
+	cmd.Stderr = &e
+
+	err := cmd.Run()
+	if err != nil {
+		if e.String() == `` {
+			return ``, err
+		}
+		return ``, errors.New(strings.TrimSpace(e.String()))
+	}
+	return b.String(), nil
+}
+
+// Fields of pacman info output, that contain lists. Wrapped lines of these
+// fields are joined with list separator.
+var infoListFields = map[string]bool{
+	"Licenses":       true,
+	"Groups":         true,
+	"Provides":       true,
+	"Depends On":     true,
+	"Optional Deps":  true,
+	"Required By":    true,
+	"Optional For":   true,
+	"Conflicts With": true,
+	"Replaces":       true,
+	"Validated By":   true,
+	"Backup Files":   true,
+}
+
+// Parse output of `pacman -Qi` or `pacman -Si` in C locale. Packages are
+// separated with empty lines, each line contains key and value separated
+// with colon, lines starting with space continue value of previous key.
+// Unknown keys are ignored.
+func ParseInfo(out string) ([]PackageInfoFull, error) {
+	var infos []PackageInfoFull
+	var fields map[string]string
+	var key string
+
+	flush := func() error {
+		if fields == nil {
+			return nil
+		}
+		info, err := infoFromFields(fields)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, *info)
+		fields = nil
+		return nil
+	}
+
+	for i, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == `` {
+			err := flush()
+			if err != nil {
+				return nil, err
+			}
+			key = ``
+			continue
+		}
+
+		// Backup files are listed one per line without indentation.
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || key == "Backup Files" {
+			if fields == nil || key == `` {
+				return nil, fmt.Errorf("line %d: continuation without field: %s", i+1, line)
+			}
+			sep := " "
+			if infoListFields[key] {
+				sep = "  "
+			}
+			value := strings.Join(strings.Fields(line), " ")
+			if fields[key] == `` || fields[key] == "None" {
+				fields[key] = value
+			} else {
+				fields[key] += sep + value
+			}
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key and value: %s", i+1, line)
+		}
+		if fields == nil {
+			fields = map[string]string{}
+		}
+		key = strings.TrimSpace(k)
+		fields[key] = strings.TrimSpace(v)
+	}
+
+	err := flush()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, errors.New("no package information found in pacman output")
+	}
+	return infos, nil
+}
+
+// Form package info from parsed fields, package name and version are
+// required.
+func infoFromFields(f map[string]string) (*PackageInfoFull, error) {
+	if f["Name"] == `` || f["Version"] == `` {
+		return nil, errors.New("package information without name or version")
+	}
+	return &PackageInfoFull{
+		Name:          f["Name"],
+		Version:       f["Version"],
+		Description:   f["Description"],
+		Architecture:  f["Architecture"],
+		URL:           f["URL"],
+		Licenses:      f["Licenses"],
+		Groups:        f["Groups"],
+		Provides:      f["Provides"],
+		DependsOn:     f["Depends On"],
+		OptionalDeps:  f["Optional Deps"],
+		RequiredBy:    f["Required By"],
+		OptionalFor:   f["Optional For"],
+		ConflictsWith: f["Conflicts With"],
+		Replaces:      f["Replaces"],
+		InstalledSize: f["Installed Size"],
+		Packager:      f["Packager"],
+		BuildDate:     f["Build Date"],
+		InstallDate:   f["Install Date"],
+		InstallReason: f["Install Reason"],
+		InstallScript: f["Install Script"],
+		ValidatedBy:   f["Validated By"],
+		Repository:    f["Repository"],
+		DownloadSize:  f["Download Size"],
+		BackupFiles:   f["Backup Files"],
+	}, nil
+}
@@ -0,0 +1,70 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const infoOutput = `Name            : nano
+Version         : 7.2-1
+Description     : Pico editor clone with enhancements
+Architecture    : x86_64
+URL             : https://www.nano-editor.org
+Licenses        : GPL
+Groups          : None
+Provides        : None
+Depends On      : glibc  file  ncurses  zlib
+Optional Deps   : None
+Required By     : None
+Optional For    : None
+Conflicts With  : None
+Replaces        : None
+Installed Size  : 2.50 MiB
+Packager        : John Doe <john@example.com>
+Build Date      : Sat Jan 13 10:00:00 2024
+Install Date    : Mon Jan  1 08:30:00 2024
+Install Reason  : Explicitly installed
+Install Script  : No
+Validated By    : Signature
+Backup Files    :
+UNMODIFIED	/etc/nanorc
+
+Name            : git
+Version         : 2.43.0-1
+Depends On      : curl  expat  perl
+Optional Deps   : tk: gitk and git gui
+                  openssh: ssh transport and crypto
+                  perl-libwww: git svn [installed]
+Installed Size  : 27.01 MiB
+
+`
+
+func TestParseInfo(t *testing.T) {
+	infos, err := ParseInfo(infoOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(infos))
+	assert.Equal(t, "nano", infos[0].Name)
+	assert.Equal(t, "glibc  file  ncurses  zlib", infos[0].DependsOn)
+	assert.Equal(t, "UNMODIFIED /etc/nanorc", infos[0].BackupFiles)
+	expected := "tk: gitk and git gui  openssh: ssh transport and crypto  perl-libwww: git svn [installed]"
+	assert.Equal(t, expected, infos[1].OptionalDeps)
+	assert.Equal(t, ``, infos[1].Description)
+	assert.Equal(t, "27.01 MiB", infos[1].InstalledSize)
+}
+
+func TestParseInfoErrors(t *testing.T) {
+	for _, out := range []string{
+		``,
+		"Version         : 1-1\n",
+		"Name            : nano\nnot a field\n",
+		"    continuation\n",
+	} {
+		_, err := ParseInfo(out)
+		assert.Error(t, err, out)
+	}
+}
@@ -10,9 +10,10 @@ import (
 
 // Used commands.
 const (
-	pacman  = `pacman`
-	makepkg = `makepkg`
-	repoadd = `repo-add`
+	pacman     = `pacman`
+	makepkg    = `makepkg`
+	repoadd    = `repo-add`
+	reporemove = `repo-remove`
 )
 
 // Global lock for operations with pacman database.
@@ -102,6 +102,28 @@ func makepkgdefault() *MakepkgParameters {
 func Makepkg(opts ...MakepkgParameters) error {
 	p := formOptions(opts, makepkgdefault)
 
+	if p.Install {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	if p.SyncDeps && !p.Install {
+		if mu.TryLock() {
+			defer mu.Unlock()
+		}
+	}
+
+	return process.Command(&process.Params{
+		Stdout:  p.Stdout,
+		Stderr:  p.Stderr,
+		Stdin:   p.Stdin,
+		Command: makepkg,
+		Args:    p.Args(),
+		Dir:     p.Dir,
+	}).Run()
+}
+
+// Form makepkg command line arguements from parameters.
+func (p *MakepkgParameters) Args() []string {
 	var args []string
 	if p.IgnoreEach {
 		args = append(args, "--ignorearch")
@@ -192,23 +214,9 @@ func Makepkg(opts ...MakepkgParameters) error {
 	}
 	if p.Install {
 		args = append(args, "--install")
-		mu.Lock()
-		defer mu.Unlock()
 	}
 	if p.SyncDeps {
 		args = append(args, "--syncdeps")
-		if mu.TryLock() {
-			defer mu.Unlock()
-		}
 	}
-	args = append(args, p.AdditionalParams...)
-
-	return process.Command(&process.Params{
-		Stdout:  p.Stdout,
-		Stderr:  p.Stderr,
-		Stdin:   p.Stdin,
-		Command: makepkg,
-		Args:    args,
-		Dir:     p.Dir,
-	}).Run()
+	return append(args, p.AdditionalParams...)
 }
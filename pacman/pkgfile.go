@@ -0,0 +1,40 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Parts of package file name in name-pkgver-pkgrel-arch.pkg.tar.ext format.
+type PackageFile struct {
+	Name string
+	// Version with epoch and release, such as 2:1.0.1-3.
+	Version string
+	Arch    string
+}
+
+// Split package file name or path to package name, version and architecture.
+// Names can contain dashes, so version, release and architecture are taken
+// from the end of file name.
+func ParsePackageFile(filename string) (PackageFile, error) {
+	base := filepath.Base(filename)
+	i := strings.Index(base, ".pkg.tar")
+	if i < 0 {
+		return PackageFile{}, errors.New("not valid package file name: " + base)
+	}
+	splt := strings.Split(base[:i], "-")
+	if len(splt) < 4 || splt[0] == `` || slices.Contains(splt[len(splt)-3:], ``) {
+		return PackageFile{}, errors.New("not valid package file name: " + base)
+	}
+	return PackageFile{
+		Name:    strings.Join(splt[:len(splt)-3], "-"),
+		Version: splt[len(splt)-3] + "-" + splt[len(splt)-2],
+		Arch:    splt[len(splt)-1],
+	}, nil
+}
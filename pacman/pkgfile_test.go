@@ -0,0 +1,26 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestParsePackageFile(t *testing.T) {
+	f, err := ParsePackageFile("/var/cache/pacman/pkg/python-foo-bar-2:1.0.1-3-any.pkg.tar.zst")
+	assert.NoError(t, err)
+	assert.Equal(t, PackageFile{Name: "python-foo-bar", Version: "2:1.0.1-3", Arch: "any"}, f)
+
+	f, err = ParsePackageFile("nano-7.2-1-x86_64.pkg.tar.xz.sig")
+	assert.NoError(t, err)
+	assert.Equal(t, PackageFile{Name: "nano", Version: "7.2-1", Arch: "x86_64"}, f)
+
+	for _, filename := range []string{"broken-1.pkg.tar.zst", "nano-7.2-1-x86_64.tar.zst", "-7.2-1-x86_64.pkg.tar.zst", "nano--1-x86_64.pkg.tar.zst"} {
+		_, err = ParsePackageFile(filename)
+		assert.Error(t, err, filename)
+	}
+}
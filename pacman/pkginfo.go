@@ -0,0 +1,179 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Metadata stored in .PKGINFO file inside of package archive.
+type Pkginfo struct {
+	Name         string
+	Base         string
+	Version      string
+	Description  string
+	URL          string
+	BuildDate    int64
+	Packager     string
+	Size         int64
+	Arch         string
+	Licenses     []string
+	Groups       []string
+	Depends      []string
+	OptDepends   []string
+	MakeDepends  []string
+	CheckDepends []string
+	Provides     []string
+	Conflicts    []string
+	Replaces     []string
+	Backup       []string
+}
+
+// Read .PKGINFO metadata from package archive.
+func ReadPkginfo(pkgfile string) (*Pkginfo, error) {
+	var info *Pkginfo
+	err := WalkPackage(pkgfile, func(hdr *tar.Header, r io.Reader) error {
+		if hdr.Name != ".PKGINFO" {
+			return nil
+		}
+		var err error
+		info, err = parsePkginfo(r)
+		if err != nil {
+			return err
+		}
+		return ErrStopWalk
+	})
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, errors.New("no .PKGINFO in package: " + pkgfile)
+	}
+	return info, nil
+}
+
+// Return this error from walk function to stop reading archive.
+var ErrStopWalk = errors.New("stop walk")
+
+// Call provided function for each entry in package archive. Archive can be
+// compressed with zstd, gzip or not compressed at all.
+func WalkPackage(pkgfile string, fn func(*tar.Header, io.Reader) error) error {
+	f, err := os.Open(pkgfile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := Decompress(f)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = fn(hdr, tr)
+		if errors.Is(err, ErrStopWalk) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Detect compression by magic bytes and wrap reader with decompressor.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(br)
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+func parsePkginfo(r io.Reader) (*Pkginfo, error) {
+	var info Pkginfo
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == `` || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "pkgname":
+			info.Name = value
+		case "pkgbase":
+			info.Base = value
+		case "pkgver":
+			info.Version = value
+		case "pkgdesc":
+			info.Description = value
+		case "url":
+			info.URL = value
+		case "builddate":
+			info.BuildDate, _ = strconv.ParseInt(value, 10, 64)
+		case "packager":
+			info.Packager = value
+		case "size":
+			info.Size, _ = strconv.ParseInt(value, 10, 64)
+		case "arch":
+			info.Arch = value
+		case "license":
+			info.Licenses = append(info.Licenses, value)
+		case "group":
+			info.Groups = append(info.Groups, value)
+		case "depend":
+			info.Depends = append(info.Depends, value)
+		case "optdepend":
+			info.OptDepends = append(info.OptDepends, value)
+		case "makedepend":
+			info.MakeDepends = append(info.MakeDepends, value)
+		case "checkdepend":
+			info.CheckDepends = append(info.CheckDepends, value)
+		case "provides":
+			info.Provides = append(info.Provides, value)
+		case "conflict":
+			info.Conflicts = append(info.Conflicts, value)
+		case "replaces":
+			info.Replaces = append(info.Replaces, value)
+		case "backup":
+			info.Backup = append(info.Backup, value)
+		}
+	}
+	return &info, scanner.Err()
+}
@@ -129,49 +129,21 @@ type PackageInfoFull struct {
 	InstallReason string
 	InstallScript string
 	ValidatedBy   string
+	// Sync database, only for sync packages.
+	Repository string
+	// Size of package file, only for sync packages.
+	DownloadSize string
+	// Backup files with their state, only with -ii.
+	BackupFiles string
 }
 
-// Get info about package.
+// Get info about installed package.
 func Info(pkg string) (*PackageInfoFull, error) {
-	var b bytes.Buffer
-	cmd := exec.Command(pacman, "-Qi", pkg)
-	cmd.Stdout = &b
-	cmd.Stderr = &b
-
-	err := cmd.Run()
+	infos, err := InfoList([]string{pkg})
 	if err != nil {
-		return nil, errors.New("unable to get info: " + b.String())
+		return nil, err
 	}
-	out := b.String()
-
-	return &PackageInfoFull{
-		Name:          parseField(out, "Name            : "),
-		Version:       parseField(out, "Version         : "),
-		Description:   parseField(out, "Description     : "),
-		Architecture:  parseField(out, "Architecture    : "),
-		URL:           parseField(out, "URL             : "),
-		Licenses:      parseField(out, "Licenses        : "),
-		Groups:        parseField(out, "Groups          : "),
-		Provides:      parseField(out, "Provides        : "),
-		DependsOn:     parseField(out, "Depends On      : "),
-		OptionalDeps:  parseField(out, "Optional Deps   : "),
-		RequiredBy:    parseField(out, "Required By     : "),
-		OptionalFor:   parseField(out, "Optional For    : "),
-		ConflictsWith: parseField(out, "Conflicts With  : "),
-		Replaces:      parseField(out, "Replaces        : "),
-		InstalledSize: parseField(out, "Installed Size  : "),
-		Packager:      parseField(out, "Packager        : "),
-		BuildDate:     parseField(out, "Build Date      : "),
-		InstallDate:   parseField(out, "Install Date    : "),
-		InstallReason: parseField(out, "Install Reason  : "),
-		InstallScript: parseField(out, "Install Script  : "),
-		ValidatedBy:   parseField(out, "Validated By    : "),
-	}, nil
-}
-
-func parseField(full string, field string) string {
-	splt := strings.Split(full, field)
-	return strings.Split(splt[1], "\n")[0]
+	return &infos[0], nil
 }
 
 // Outdated package.
@@ -179,6 +151,8 @@ type OutdatedPackage struct {
 	Name           string
 	CurrentVersion string
 	NewVersion     string
+	// Sync database, that contains new version, if known.
+	Repository string
 }
 
 // Get information about outdated packages.
@@ -227,3 +201,24 @@ func RawFileInfo(filepath string) (string, error) {
 	}
 	return b.String(), nil
 }
+
+// Get packages installed as dependencies, that are not required by any other
+// installed package.
+func Orphans() ([]string, error) {
+	var b bytes.Buffer
+	err := Query(nil, QueryParameters{
+		Stdout:           &b,
+		Stderr:           &b,
+		Deps:             true,
+		Unrequired:       true,
+		AdditionalParams: []string{"--quiet"},
+	})
+	if err != nil {
+		// Pacman exits with error, when there are no orphans.
+		if b.String() == `` {
+			return nil, nil
+		}
+		return nil, errors.New("unable to get orphans: " + b.String())
+	}
+	return strings.Fields(b.String()), nil
+}
@@ -0,0 +1,80 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"io"
+	"os"
+
+	"ion.lc/core/tab/process"
+)
+
+// Parameters for removing packages from pacman repo.
+type RepoRemoveParameters struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// Additional parameters, that will be appended to command as arguements.
+	AdditionalParams []string
+	// Directory where process will be executed.
+	Dir string
+	// Use the specified key to sign the database. [--key <file>]
+	Key string
+	// Run with sudo priveleges. [sudo]
+	Sudo bool
+	// Turn off color in output. [--nocolor]
+	NoColor bool
+	// Sign database with GnuPG after update. [--sign]
+	Sign bool
+	// Verify database signature before update. [--verify]
+	Verify bool
+}
+
+func RepoRemoveDefaultOptions() *RepoRemoveParameters {
+	return &RepoRemoveParameters{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
+	}
+}
+
+// This function will remove packages from database. You should provide valid
+// path for database file and names of packages you want to remove.
+func RepoRemove(dbfile string, pkgs []string, opts ...RepoRemoveParameters) error {
+	dbmu.Lock()
+	defer dbmu.Unlock()
+
+	p := formOptions(opts, RepoRemoveDefaultOptions)
+
+	var args []string
+	if p.NoColor {
+		args = append(args, "--nocolor")
+	}
+	if p.Sign {
+		args = append(args, "--sign")
+	}
+	if p.Verify {
+		args = append(args, "--verify")
+	}
+	if p.Key != "" {
+		args = append(args, "--key")
+		args = append(args, p.Key)
+	}
+
+	args = append(args, p.AdditionalParams...)
+	args = append(args, dbfile)
+	args = append(args, pkgs...)
+
+	return process.Command(&process.Params{
+		Stdout:  p.Stdout,
+		Stderr:  p.Stderr,
+		Stdin:   p.Stdin,
+		Dir:     p.Dir,
+		Sudo:    p.Sudo,
+		Command: reporemove,
+		Args:    args,
+	}).Run()
+}
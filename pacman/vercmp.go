@@ -0,0 +1,139 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import "strings"
+
+// Compare package versions in [epoch:]version[-release] format the same way
+// pacman does. Returns -1 if a is older than b, 1 if a is newer than b and 0
+// if versions are equal.
+func Vercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	epoch1, ver1, rel1 := parseEVR(a)
+	epoch2, ver2, rel2 := parseEVR(b)
+
+	ret := rpmvercmp(epoch1, epoch2)
+	if ret == 0 {
+		ret = rpmvercmp(ver1, ver2)
+		if ret == 0 && rel1 != `` && rel2 != `` {
+			ret = rpmvercmp(rel1, rel2)
+		}
+	}
+	return ret
+}
+
+// Split version to epoch, version and release, epoch is 0 if missing and
+// release is empty if missing.
+func parseEVR(evr string) (string, string, string) {
+	epoch := "0"
+	i := 0
+	for i < len(evr) && isDigit(evr[i]) {
+		i++
+	}
+	version := evr
+	if i < len(evr) && evr[i] == ':' {
+		if i > 0 {
+			epoch = evr[:i]
+		}
+		version = evr[i+1:]
+	}
+	var release string
+	if j := strings.LastIndex(version, "-"); j >= 0 {
+		release = version[j+1:]
+		version = version[:j]
+	}
+	return epoch, version, release
+}
+
+// Port of rpmvercmp from libalpm, compares alternating numeric and alpha
+// segments of version strings.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	var one, two, ptr1, ptr2 int
+	for one < len(a) && two < len(b) {
+		for one < len(a) && !isAlnum(a[one]) {
+			one++
+		}
+		for two < len(b) && !isAlnum(b[two]) {
+			two++
+		}
+		if one >= len(a) || two >= len(b) {
+			break
+		}
+
+		// If separator lengths are different, versions are different.
+		if one-ptr1 != two-ptr2 {
+			if one-ptr1 < two-ptr2 {
+				return -1
+			}
+			return 1
+		}
+
+		ptr1, ptr2 = one, two
+		isnum := isDigit(a[ptr1])
+		segment := isAlpha
+		if isnum {
+			segment = isDigit
+		}
+		for ptr1 < len(a) && segment(a[ptr1]) {
+			ptr1++
+		}
+		for ptr2 < len(b) && segment(b[ptr2]) {
+			ptr2++
+		}
+
+		// Numeric segments are always newer than alpha segments.
+		if two == ptr2 {
+			if isnum {
+				return 1
+			}
+			return -1
+		}
+
+		seg1, seg2 := a[one:ptr1], b[two:ptr2]
+		if isnum {
+			seg1 = strings.TrimLeft(seg1, "0")
+			seg2 = strings.TrimLeft(seg2, "0")
+			if len(seg1) > len(seg2) {
+				return 1
+			}
+			if len(seg2) > len(seg1) {
+				return -1
+			}
+		}
+		if c := strings.Compare(seg1, seg2); c != 0 {
+			return c
+		}
+
+		one, two = ptr1, ptr2
+	}
+
+	if one >= len(a) && two >= len(b) {
+		return 0
+	}
+
+	// Remaining alpha string never beats an empty string.
+	if (one >= len(a) && !isAlpha(b[two])) || (one < len(a) && isAlpha(a[one])) {
+		return -1
+	}
+	return 1
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || isAlpha(c)
+}
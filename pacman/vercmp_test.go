@@ -0,0 +1,40 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package pacman
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestVercmp(t *testing.T) {
+	cases := []struct {
+		a, b string
+		ret  int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.1", "1.0", 1},
+		{"1.0.0", "1.0", 1},
+		{"1.0a", "1.0", -1},
+		{"1.0", "1.0b", 1},
+		{"1.0alpha", "1.0beta", -1},
+		{"1.0.a", "1.0.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.001", "1.1", 0},
+		{"1.0-1", "1.0-2", -1},
+		{"1.0-2", "1.0", 0},
+		{"1:1.0", "2.0", 1},
+		{"0:1.0", "1.0", 0},
+		{"1.0_1", "1.0.1", 0},
+		{"1.0..1", "1.0.1", 1},
+		{"r100.abc", "r99.def", 1},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.ret, Vercmp(c.a, c.b), c.a+" <> "+c.b)
+		assert.Equal(t, -c.ret, Vercmp(c.b, c.a), c.b+" <> "+c.a)
+	}
+}
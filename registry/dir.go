@@ -0,0 +1,404 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package registry
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/ioprogress"
+	"ion.lc/core/tab/pacman"
+)
+
+// Plain directory repository, which can be located on local or mounted
+// file system. Packages are stored in root/distro/arch directories together
+// with pacman database, maintained with repo-add.
+type Dir struct {
+	// Root directory of repository.
+	Root string
+}
+
+// Default architecture directory for architecture independent packages, when
+// repository does not have any architectures yet.
+const defaultArch = "x86_64"
+
+func (d *Dir) String() string {
+	return "file://" + d.Root
+}
+
+func (d *Dir) Push(p *PushParameters) (int, error) {
+	info, err := pacman.ReadPkginfo(p.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	archs := []string{info.Arch}
+	if info.Arch == "any" {
+		archs, err = d.architectures(p.Distro)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	filename := filepath.Base(p.Path)
+	for _, arch := range archs {
+		archdir := filepath.Join(d.Root, p.Distro, arch)
+		_, err := os.Stat(filepath.Join(archdir, filename))
+		if err == nil {
+			return 0, &RegistryError{
+				Message: filename + " already exists in " + archdir,
+				Err:     ErrVersionExists,
+			}
+		}
+	}
+
+	for _, arch := range archs {
+		archdir := filepath.Join(d.Root, p.Distro, arch)
+		err := os.MkdirAll(archdir, 0o755)
+		if err != nil {
+			return 0, err
+		}
+
+		dst := filepath.Join(archdir, filename)
+		err = copyFile(p.Path, dst, p.Progress)
+		if err != nil {
+			return 0, err
+		}
+		err = os.WriteFile(dst+".sig", p.Signature, 0o644)
+		if err != nil {
+			return 0, err
+		}
+
+		err = pacman.RepoAdd(d.dbfile(p.Distro, arch), dst, pacman.RepoAddParameters{
+			Stdout: io.Discard,
+			Stderr: os.Stderr,
+			Stdin:  os.Stdin,
+		})
+		if err != nil {
+			return 0, errors.Join(err, os.Remove(dst), os.Remove(dst+".sig"))
+		}
+	}
+	return 0, nil
+}
+
+func (d *Dir) Remove(p *RemoveParameters) (int, []Target, error) {
+	var targets []Target
+	err := d.walkArchs(func(distro, arch string) error {
+		if (p.Distro != `` && p.Distro != distro) || (p.Arch != `` && p.Arch != arch) {
+			return nil
+		}
+		archdir := filepath.Join(d.Root, distro, arch)
+		files, err := d.packageFiles(archdir, p.Name)
+		if err != nil {
+			return err
+		}
+
+		var remaining []dirFile
+		for _, file := range files {
+			if file.Version != p.Version {
+				remaining = append(remaining, file)
+				continue
+			}
+			err = os.Remove(filepath.Join(archdir, file.Filename))
+			if err != nil {
+				return err
+			}
+			err = os.Remove(filepath.Join(archdir, file.Filename+".sig"))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		if len(remaining) == len(files) {
+			return nil
+		}
+		targets = append(targets, Target{Distro: distro, Arch: arch})
+
+		if len(remaining) == 0 {
+			return pacman.RepoRemove(d.dbfile(distro, arch), []string{p.Name},
+				pacman.RepoRemoveParameters{
+					Stdout: io.Discard,
+					Stderr: os.Stderr,
+					Stdin:  os.Stdin,
+				},
+			)
+		}
+
+		// Restore database entry with highest of remaining versions.
+		latest := latestFile(remaining)
+		return pacman.RepoAdd(d.dbfile(distro, arch), filepath.Join(archdir, latest.Filename),
+			pacman.RepoAddParameters{
+				Stdout: io.Discard,
+				Stderr: os.Stderr,
+				Stdin:  os.Stdin,
+			},
+		)
+	})
+	if err != nil {
+		return 0, targets, err
+	}
+	if len(targets) == 0 {
+		return 0, nil, &RegistryError{
+			Message: p.Name + "@" + p.Version + " not found in " + d.Root,
+			Err:     ErrPackageNotFound,
+		}
+	}
+
+	// Attachments are kept while version exists in any architecture.
+	files, err := d.Files(p.Name, p.Version)
+	if err != nil || len(files) > 0 {
+		return 0, targets, err
+	}
+	return 0, targets, os.RemoveAll(d.attachments(p.Name, p.Version))
+}
+
+func (d *Dir) List(name string) ([]Package, error) {
+	versions := map[string]Package{}
+	err := d.walkArchs(func(distro, arch string) error {
+		archdir := filepath.Join(d.Root, distro, arch)
+		files, err := d.packageFiles(archdir, name)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			info, err := os.Stat(filepath.Join(archdir, file.Filename))
+			if err != nil {
+				return err
+			}
+			key := file.Name + "@" + file.Version
+			if pkg, ok := versions[key]; ok && pkg.Created.Before(info.ModTime()) {
+				continue
+			}
+			versions[key] = Package{
+				Name:    file.Name,
+				Version: file.Version,
+				Created: info.ModTime(),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	for _, pkg := range versions {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Created.Before(pkgs[j].Created)
+	})
+	return pkgs, nil
+}
+
+func (d *Dir) Files(name, version string) ([]File, error) {
+	var files []File
+	err := d.walkArchs(func(distro, arch string) error {
+		archdir := filepath.Join(d.Root, distro, arch)
+		found, err := d.packageFiles(archdir, name)
+		if err != nil {
+			return err
+		}
+		for _, file := range found {
+			if file.Version != version {
+				continue
+			}
+			if slices.ContainsFunc(files, func(f File) bool { return f.Name == file.Filename }) {
+				continue
+			}
+			info, err := os.Stat(filepath.Join(archdir, file.Filename))
+			if err != nil {
+				return err
+			}
+			files = append(files, File{Name: file.Filename, Size: info.Size()})
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (d *Dir) Download(distro, arch, file string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(d.Root, distro, arch, filepath.Base(file)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &RegistryError{
+			Message: file + " not found in " + d.Root,
+			Err:     ErrPackageNotFound,
+		}
+	}
+	return f, err
+}
+
+// Attachments are stored in hidden directory of repository root, separately
+// for each package version.
+func (d *Dir) Attach(name, version, file string, data []byte) (int, error) {
+	dir := d.attachments(name, version)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return 0, err
+	}
+	return 0, os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0o644)
+}
+
+func (d *Dir) Attachment(name, version, file string) ([]byte, error) {
+	b, err := os.ReadFile(filepath.Join(d.attachments(name, version), filepath.Base(file)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &RegistryError{
+			Message: file + " not found for " + name + "@" + version,
+			Err:     ErrPackageNotFound,
+		}
+	}
+	return b, err
+}
+
+func (d *Dir) DatabaseName() string {
+	return filepath.Base(d.Root)
+}
+
+func (d *Dir) DatabaseServer(distro, arch string) string {
+	return "file://" + filepath.Join(d.Root, distro, arch)
+}
+
+func (d *Dir) Database(distro, arch string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(d.Root, distro, arch, d.DatabaseName()+".db"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// Directory with attachments of package version.
+func (d *Dir) attachments(name, version string) string {
+	return filepath.Join(d.Root, ".attachments", filepath.Base(name), filepath.Base(version))
+}
+
+// Path to database file for distribution and architecture.
+func (d *Dir) dbfile(distro, arch string) string {
+	return filepath.Join(d.Root, distro, arch, d.DatabaseName()+".db.tar.gz")
+}
+
+// List architectures present for distribution in repository.
+func (d *Dir) architectures(distro string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(d.Root, distro))
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{defaultArch}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var archs []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != "any" {
+			archs = append(archs, entry.Name())
+		}
+	}
+	if len(archs) == 0 {
+		return []string{defaultArch}, nil
+	}
+	return archs, nil
+}
+
+// Call function for each distribution and architecture in repository.
+func (d *Dir) walkArchs(fn func(distro, arch string) error) error {
+	distros, err := os.ReadDir(d.Root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, distro := range distros {
+		if !distro.IsDir() || strings.HasPrefix(distro.Name(), ".") {
+			continue
+		}
+		archs, err := os.ReadDir(filepath.Join(d.Root, distro.Name()))
+		if err != nil {
+			return err
+		}
+		for _, arch := range archs {
+			if !arch.IsDir() {
+				continue
+			}
+			err = fn(distro.Name(), arch.Name())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Package file stored in repository directory.
+type dirFile struct {
+	pacman.PackageFile
+	Filename string
+}
+
+// List package files with provided package name in directory, all package
+// files are listed if name is empty. Files with not valid names are skipped.
+func (d *Dir) packageFiles(dir, name string) ([]dirFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []dirFile
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".pkg.tar.zst") {
+			continue
+		}
+		parsed, err := pacman.ParsePackageFile(entry.Name())
+		if err != nil {
+			continue
+		}
+		if name == `` || parsed.Name == name {
+			files = append(files, dirFile{PackageFile: parsed, Filename: entry.Name()})
+		}
+	}
+	return files, nil
+}
+
+// Get file with highest package version from the list.
+func latestFile(files []dirFile) dirFile {
+	latest := files[0]
+	for _, file := range files[1:] {
+		if pacman.Vercmp(file.Version, latest.Version) > 0 {
+			latest = file
+		}
+	}
+	return latest
+}
+
+// Copy file contents, drawing progress if draw function is provided.
+func copyFile(src, dst string, draw func(int64, int64) error) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = in
+	if draw != nil {
+		r = &ioprogress.Reader{Reader: in, Size: info.Size(), DrawFunc: draw}
+	}
+	_, err = io.Copy(out, r)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,31 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestDirLatestFile(t *testing.T) {
+	dir := t.TempDir()
+	// Older version is modified last, as if it was pushed again.
+	for i, file := range []string{"pkg-1.10-1-x86_64.pkg.tar.zst", "pkg-1.9-1-x86_64.pkg.tar.zst", "pkg-tools-2-1-x86_64.pkg.tar.zst", "broken.pkg.tar.zst"} {
+		path := filepath.Join(dir, file)
+		assert.NoError(t, os.WriteFile(path, nil, 0o644))
+		modified := time.Now().Add(time.Duration(i) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, modified, modified))
+	}
+
+	d := &Dir{Root: dir}
+	files, err := d.packageFiles(dir, "pkg")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+	assert.Equal(t, "pkg-1.10-1-x86_64.pkg.tar.zst", latestFile(files).Filename)
+}
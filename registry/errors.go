@@ -0,0 +1,119 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"ion.lc/core/tab/msgs"
+)
+
+// Registry errors, that could be checked with errors.Is.
+var (
+	ErrUnauthorized     = errors.New("registry authorization failed")
+	ErrVersionExists    = errors.New("package version already exists in registry")
+	ErrInvalidSignature = errors.New("invalid package signature")
+	ErrPackageNotFound  = errors.New("package not found in registry")
+	ErrQuotaExceeded    = errors.New("registry quota exceeded")
+)
+
+// Error returned by registry API.
+type RegistryError struct {
+	// HTTP status of registry response, 0 for directory repositories.
+	Status int
+	// Message provided by registry in response body.
+	Message string
+	// One of registry error sentinels, nil if error is not recognized.
+	Err error
+}
+
+func (e *RegistryError) Error() string {
+	msg := e.Message
+	if e.Status != 0 {
+		msg = fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+		if e.Message != `` {
+			msg += ": " + e.Message
+		}
+	}
+	hint := registryErrorHint(e.Err)
+	if hint != `` {
+		msg += "\n\n" + hint
+	}
+	return msg
+}
+
+func (e *RegistryError) Unwrap() error {
+	return e.Err
+}
+
+// Get HTTP status from registry error, 0 for other errors.
+func ErrorStatus(err error) int {
+	var rerr *RegistryError
+	if errors.As(err, &rerr) {
+		return rerr.Status
+	}
+	return 0
+}
+
+// Actionable message for registry error sentinel.
+func registryErrorHint(err error) string {
+	switch err {
+	case ErrUnauthorized:
+		return msgs.ErrRegistryUnauthorized
+	case ErrVersionExists:
+		return msgs.ErrRegistryVersionExists
+	case ErrInvalidSignature:
+		return msgs.ErrRegistryInvalidSignature
+	case ErrPackageNotFound:
+		return msgs.ErrRegistryPackageNotFound
+	case ErrQuotaExceeded:
+		return msgs.ErrRegistryQuotaExceeded
+	}
+	return ``
+}
+
+// Build registry error from response status code and JSON error body.
+func parseRegistryError(resp *http.Response) error {
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Join(err, errors.New(resp.Status))
+	}
+
+	var body struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors"`
+	}
+	rerr := &RegistryError{Status: resp.StatusCode}
+	if json.Unmarshal(b, &body) == nil {
+		rerr.Message = body.Message
+		if rerr.Message == `` {
+			rerr.Message = strings.Join(body.Errors, ", ")
+		}
+	} else {
+		rerr.Message = strings.TrimSpace(string(b))
+	}
+
+	lower := strings.ToLower(rerr.Message)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusForbidden:
+		rerr.Err = ErrUnauthorized
+	case resp.StatusCode == http.StatusConflict:
+		rerr.Err = ErrVersionExists
+	case resp.StatusCode == http.StatusRequestEntityTooLarge,
+		strings.Contains(lower, "quota"):
+		rerr.Err = ErrQuotaExceeded
+	case strings.Contains(lower, "signature"):
+		rerr.Err = ErrInvalidSignature
+	case resp.StatusCode == http.StatusNotFound:
+		rerr.Err = ErrPackageNotFound
+	}
+	return rerr
+}
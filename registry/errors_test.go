@@ -0,0 +1,46 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package registry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestParseRegistryError(t *testing.T) {
+	cases := []struct {
+		status int
+		body   string
+		err    error
+		msg    string
+	}{
+		{401, `{"message":"invalid credentials"}`, ErrUnauthorized, "invalid credentials"},
+		{409, `{"message":"package already exists"}`, ErrVersionExists, "package already exists"},
+		{400, `{"message":"invalid signature"}`, ErrInvalidSignature, "invalid signature"},
+		{404, `{"errors":["package does not exist"]}`, ErrPackageNotFound, "package does not exist"},
+		{413, `quota exceeded`, ErrQuotaExceeded, "quota exceeded"},
+		{500, `{"message":"internal"}`, nil, "internal"},
+	}
+
+	for _, c := range cases {
+		err := parseRegistryError(&http.Response{
+			StatusCode: c.status,
+			Body:       io.NopCloser(strings.NewReader(c.body)),
+		})
+
+		var rerr *RegistryError
+		assert.True(t, errors.As(err, &rerr))
+		assert.Equal(t, c.status, rerr.Status)
+		assert.Equal(t, c.msg, rerr.Message)
+		if c.err != nil {
+			assert.True(t, errors.Is(err, c.err))
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/mitchellh/ioprogress"
+	"ion.lc/core/tab/creds"
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+)
+
+// Gitea package registry, packages are published with arch package API.
+type Gitea struct {
+	// Protocol used for API calls, http or https.
+	Protocol string
+	// Registry domain address.
+	Addr string
+	// Owner of packages in registry, user or organization.
+	Owner string
+}
+
+func (g *Gitea) String() string {
+	return path.Join(g.Addr, g.Owner)
+}
+
+func (g *Gitea) Push(p *PushParameters) (int, error) {
+	packagefile, err := os.Open(p.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer packagefile.Close()
+
+	pkgInfo, err := packagefile.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := g.Request(func() (*http.Request, error) {
+		_, err := packagefile.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		return http.NewRequest(
+			http.MethodPut,
+			g.url("api/packages", g.Owner, "arch/push", p.Distro,
+				base64.RawURLEncoding.EncodeToString(p.Signature),
+			),
+			&ioprogress.Reader{
+				Reader:   packagefile,
+				Size:     pkgInfo.Size(),
+				DrawFunc: p.Progress,
+			},
+		)
+	}, http.StatusCreated)
+	if err != nil {
+		return ErrorStatus(err), err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// Removal without distribution and architecture uses remove endpoint, which
+// deletes version from all of them. Architectures are reported from version
+// files, since registry does not return them. Removal from distribution
+// without architecture is expanded to architectures of version files.
+// Registry does not list distributions, so architecture requires distribution.
+func (g *Gitea) Remove(p *RemoveParameters) (int, []Target, error) {
+	if p.Distro == `` && p.Arch != `` {
+		return 0, nil, errors.New("distribution should be provided to remove architecture " + p.Arch + " from " + g.String())
+	}
+
+	if p.Distro == `` {
+		targets := []Target{{}}
+		if files, err := g.Files(p.Name, p.Version); err == nil {
+			targets = nil
+			for _, f := range files {
+				if parsed, err := pacman.ParsePackageFile(f.Name); err == nil {
+					targets = append(targets, Target{Arch: parsed.Arch})
+				}
+			}
+		}
+		status, err := g.delete(g.url("api/packages", g.Owner, "arch/remove", p.Name, p.Version))
+		if err != nil {
+			return status, nil, err
+		}
+		return status, targets, nil
+	}
+
+	archs := []string{p.Arch}
+	if p.Arch == `` {
+		files, err := g.Files(p.Name, p.Version)
+		if err != nil {
+			return ErrorStatus(err), nil, err
+		}
+		archs = nil
+		for _, f := range files {
+			parsed, err := pacman.ParsePackageFile(f.Name)
+			if err == nil && !slices.Contains(archs, parsed.Arch) {
+				archs = append(archs, parsed.Arch)
+			}
+		}
+		if len(archs) == 0 {
+			return http.StatusNotFound, nil, &RegistryError{
+				Status:  http.StatusNotFound,
+				Message: p.Name + "@" + p.Version + " not found in " + g.String(),
+				Err:     ErrPackageNotFound,
+			}
+		}
+	}
+
+	var status int
+	var targets []Target
+	for _, arch := range archs {
+		var err error
+		status, err = g.delete(g.url("api/packages", g.Owner, "arch", p.Distro, p.Name, p.Version, arch))
+		if err != nil {
+			return status, targets, err
+		}
+		targets = append(targets, Target{Distro: p.Distro, Arch: arch})
+	}
+	return status, targets, nil
+}
+
+// Send delete request and return response status.
+func (g *Gitea) delete(link string) (int, error) {
+	resp, err := g.Request(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodDelete, link, nil)
+	}, http.StatusNoContent)
+	if err != nil {
+		return ErrorStatus(err), err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (g *Gitea) List(name string) ([]Package, error) {
+	var pkgs []Package
+	for page := 1; ; page++ {
+		query := url.Values{
+			"type":  {"arch"},
+			"q":     {name},
+			"page":  {fmt.Sprint(page)},
+			"limit": {"50"},
+		}
+		resp, err := g.Request(func() (*http.Request, error) {
+			return http.NewRequest(
+				http.MethodGet,
+				g.url("api/v1/packages", g.Owner)+"?"+query.Encode(),
+				nil,
+			)
+		}, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+
+		var versions []struct {
+			Name      string    `json:"name"`
+			Version   string    `json:"version"`
+			CreatedAt time.Time `json:"created_at"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&versions)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range versions {
+			if name != `` && v.Name != name {
+				continue
+			}
+			pkgs = append(pkgs, Package{
+				Name:    v.Name,
+				Version: v.Version,
+				Created: v.CreatedAt,
+			})
+		}
+		if len(versions) < 50 {
+			return pkgs, nil
+		}
+	}
+}
+
+func (g *Gitea) Files(name, version string) ([]File, error) {
+	resp, err := g.Request(func() (*http.Request, error) {
+		return http.NewRequest(
+			http.MethodGet,
+			g.url("api/v1/packages", g.Owner, "arch", name, version, "files"),
+			nil,
+		)
+	}, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var files []struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&files)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []File
+	for _, f := range files {
+		result = append(result, File{Name: f.Name, Size: f.Size})
+	}
+	return result, nil
+}
+
+func (g *Gitea) Download(distro, arch, file string) (io.ReadCloser, error) {
+	body, err := g.get(g.DatabaseServer(distro, arch) + "/" + file)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, &RegistryError{
+			Status:  http.StatusNotFound,
+			Message: file + " not found in " + g.String(),
+			Err:     ErrPackageNotFound,
+		}
+	}
+	return body, nil
+}
+
+// Attachments are stored as files of generic package with same name and
+// version.
+func (g *Gitea) Attach(name, version, file string, data []byte) (int, error) {
+	link := g.url("api/packages", g.Owner, "generic", name, version, file)
+	put := func() (*http.Response, error) {
+		return g.Request(func() (*http.Request, error) {
+			return http.NewRequest(http.MethodPut, link, bytes.NewReader(data))
+		}, http.StatusCreated)
+	}
+
+	resp, err := put()
+	if errors.Is(err, ErrVersionExists) {
+		resp, err = g.Request(func() (*http.Request, error) {
+			return http.NewRequest(http.MethodDelete, link, nil)
+		}, http.StatusNoContent)
+		if err == nil {
+			resp.Body.Close()
+			resp, err = put()
+		}
+	}
+	if err != nil {
+		return ErrorStatus(err), err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (g *Gitea) Attachment(name, version, file string) ([]byte, error) {
+	body, err := g.get(g.url("api/packages", g.Owner, "generic", name, version, file))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, &RegistryError{
+			Status:  http.StatusNotFound,
+			Message: file + " not found for " + name + "@" + version,
+			Err:     ErrPackageNotFound,
+		}
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (g *Gitea) DatabaseName() string {
+	if g.Owner == `` {
+		return g.Addr
+	}
+	return g.Owner + "." + g.Addr
+}
+
+func (g *Gitea) DatabaseServer(distro, arch string) string {
+	return g.url("api/packages", g.Owner, "arch", distro, arch)
+}
+
+func (g *Gitea) Database(distro, arch string) (io.ReadCloser, error) {
+	return g.get(g.DatabaseServer(distro, arch) + "/" + g.DatabaseName() + ".db")
+}
+
+// Download file from registry, credentials are provided if they exist, since
+// packages can be private. Returns nil if file does not exist.
+func (g *Gitea) get(link string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	login, pass, err := creds.Get(g.Protocol, g.Addr)
+	if err == nil {
+		req.SetBasicAuth(login, pass)
+	}
+
+	var client http.Client
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, parseRegistryError(resp)
+	}
+	return resp.Body, nil
+}
+
+// Send authorized request to registry. Credentials are requested from user if
+// they are missing or rejected by registry. Responses with status codes that
+// are not expected are converted to RegistryError. Request is built with
+// provided function, so it could be repeated.
+func (g *Gitea) Request(newreq func() (*http.Request, error), expected ...int) (*http.Response, error) {
+	login, pass, err := creds.Get(g.Protocol, g.Addr)
+	if err != nil {
+		login, pass, err = creds.Create(g.Protocol, g.Addr, os.Stdin, os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var client http.Client
+	for attempt := 0; ; attempt++ {
+		req, err := newreq()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(login, pass)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range expected {
+			if resp.StatusCode == status {
+				return resp, nil
+			}
+		}
+
+		rerr := parseRegistryError(resp)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return nil, rerr
+		}
+
+		os.Stderr.WriteString(msgs.Err + rerr.Error() + "\n")
+		login, pass, err = creds.Create(g.Protocol, g.Addr, os.Stdin, os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Form registry link from path elements.
+func (g *Gitea) url(elem ...string) string {
+	return g.Protocol + "://" + path.Join(append([]string{g.Addr}, elem...)...)
+}
@@ -0,0 +1,53 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestGiteaScopedRemove(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/files"):
+			w.Write([]byte(`[{"name":"pkg-1-1-x86_64.pkg.tar.zst"},{"name":"pkg-1-1-aarch64.pkg.tar.zst"}]`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	err := os.WriteFile(filepath.Join(home, ".git-credentials"), []byte("http://john:password@"+addr+"\n"), 0o600)
+	assert.NoError(t, err)
+
+	g := &Gitea{Protocol: "http", Addr: addr, Owner: "team"}
+
+	_, targets, err := g.Remove(&RemoveParameters{Name: "pkg", Version: "1-1", Distro: "archlinux"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/api/packages/team/arch/archlinux/pkg/1-1/x86_64",
+		"/api/packages/team/arch/archlinux/pkg/1-1/aarch64",
+	}, deleted)
+	assert.Equal(t, []Target{{Distro: "archlinux", Arch: "x86_64"}, {Distro: "archlinux", Arch: "aarch64"}}, targets)
+
+	deleted = nil
+	_, _, err = g.Remove(&RemoveParameters{Name: "pkg", Version: "1-1", Arch: "x86_64"})
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(deleted))
+}
@@ -0,0 +1,140 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package registry
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Registry is a backend, where arch packages are published and pacman
+// databases are served from.
+type Registry interface {
+	// Push package file with detached signature to provided distribution.
+	// Returns status of registry response, HTTP status for web registries
+	// and 0 for directory repositories.
+	Push(p *PushParameters) (int, error)
+	// Remove package version from registry. Returns status of registry
+	// response and distribution and architecture combinations, that
+	// version was removed from.
+	Remove(p *RemoveParameters) (int, []Target, error)
+	// List published versions of package, all packages are listed if name
+	// is empty.
+	List(name string) ([]Package, error)
+	// List files of published package version.
+	Files(name, version string) ([]File, error)
+	// Download package or signature file for distribution and architecture.
+	Download(distro, arch, file string) (io.ReadCloser, error)
+	// Store additional file for package version, such as build provenance.
+	// Existing file with same name is replaced.
+	Attach(name, version, file string, data []byte) (int, error)
+	// Read additional file stored for package version.
+	Attachment(name, version, file string) ([]byte, error)
+	// Name of pacman database, that should be used in pacman.conf.
+	DatabaseName() string
+	// Link to pacman database server for distribution and architecture.
+	DatabaseServer(distro, arch string) string
+	// Read pacman database for distribution and architecture, returns nil
+	// if database does not exist yet.
+	Database(distro, arch string) (io.ReadCloser, error)
+	// Human readable registry location.
+	String() string
+}
+
+// Parameters of pushed package.
+type PushParameters struct {
+	// Path to package file.
+	Path string
+	// Detached package signature.
+	Signature []byte
+	// Distribution in registry, that package is pushed to.
+	Distro string
+	// Optional function to draw upload progress.
+	Progress func(int64, int64) error
+}
+
+// Parameters of removed package version.
+type RemoveParameters struct {
+	Name    string
+	Version string
+	// Optional distribution and architecture, that version is removed
+	// from, version is removed from all of them if empty.
+	Distro string
+	Arch   string
+}
+
+// Distribution and architecture combination in registry, empty fields mean
+// that combination is not known.
+type Target struct {
+	Distro string
+	Arch   string
+}
+
+func (t Target) String() string {
+	distro, arch := t.Distro, t.Arch
+	if distro == `` {
+		distro = "*"
+	}
+	if arch == `` {
+		arch = "*"
+	}
+	return distro + "/" + arch
+}
+
+// Package version published in registry.
+type Package struct {
+	Name    string
+	Version string
+	Created time.Time
+}
+
+// File of package version published in registry.
+type File struct {
+	Name string
+	Size int64
+}
+
+// Open registry for provided location. Directory repositories are provided
+// with file:// prefix, other locations are treated as web registries in
+// [protocol://]address[/owner] format.
+func Open(location string, insecure bool) (Registry, error) {
+	if strings.HasPrefix(location, "file://") {
+		root := strings.TrimPrefix(location, "file://")
+		if root == `` || root == "/" {
+			return nil, errors.New("not valid repository directory: " + location)
+		}
+		return &Dir{Root: strings.TrimSuffix(root, "/")}, nil
+	}
+
+	protocol := "https"
+	if insecure {
+		protocol = "http"
+	}
+	if splt := strings.SplitN(location, "://", 2); len(splt) == 2 {
+		protocol = splt[0]
+		location = splt[1]
+	}
+
+	splt := strings.Split(strings.Trim(location, "/"), "/")
+	switch len(splt) {
+	case 1:
+		return &Gitea{Protocol: protocol, Addr: splt[0]}, nil
+	case 2:
+		return &Gitea{Protocol: protocol, Addr: splt[0], Owner: splt[1]}, nil
+	}
+	return nil, errors.New("not valid registry: " + location)
+}
+
+// Split argument in [protocol://]registry[/owner]/package format to registry
+// location and package name.
+func SplitPackage(arg string) (string, string, error) {
+	i := strings.LastIndex(arg, "/")
+	if i <= 0 || i == len(arg)-1 || strings.HasSuffix(arg[:i], ":/") {
+		return ``, ``, errors.New("no registry for package: " + arg)
+	}
+	return arg[:i], arg[i+1:], nil
+}
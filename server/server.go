@@ -0,0 +1,410 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
+)
+
+// Self-hosted package registry. Server implements subset of Gitea arch
+// package API, that is used by tab: push, remove, version list and database
+// with package downloads for pacman. Packages of each owner are stored as
+// directory repository in root/owner.
+type Server struct {
+	// Root directory, where owner repositories are stored.
+	Root string
+	// Users allowed to push and remove packages.
+	Users Users
+	// Optional GnuPG home directory with keys of trusted signers, default
+	// keyring of user running server is used if empty.
+	GnuPGHome string
+	// Maximum size of uploaded package in bytes, defaultMaxPackageSize is
+	// used if zero.
+	MaxPackageSize int64
+
+	// Modifications of repositories are serialized, since repo-add does
+	// not support concurrent database updates.
+	mu sync.Mutex
+}
+
+// Limits of request bodies, attachments are small metadata documents.
+const (
+	defaultMaxPackageSize = 4 << 30
+	maxAttachmentSize     = 16 << 20
+)
+
+// Get HTTP handler serving registry API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /api/packages/{owner}/arch/push/{distro}/{sig}", s.auth(s.push))
+	mux.HandleFunc("DELETE /api/packages/{owner}/arch/remove/{name}/{version}", s.auth(s.remove))
+	mux.HandleFunc("DELETE /api/packages/{owner}/arch/{distro}/{name}/{version}/{arch}", s.auth(s.remove))
+	mux.HandleFunc("GET /api/packages/{owner}/arch/{distro}/{arch}/{file}", s.download)
+	mux.HandleFunc("PUT /api/packages/{owner}/generic/{name}/{version}/{file}", s.auth(s.attach))
+	mux.HandleFunc("GET /api/packages/{owner}/generic/{name}/{version}/{file}", s.attachment)
+	mux.HandleFunc("GET /api/v1/packages/{owner}", s.list)
+	mux.HandleFunc("GET /api/v1/packages/{owner}/arch/{name}/{version}/files", s.files)
+	return mux
+}
+
+// Start serving registry on provided address.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, logRequests(s.Handler()))
+}
+
+// Check basic auth credentials before passing request to handler. Users can
+// modify only packages of owner with their own login.
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		login, pass, ok := r.BasicAuth()
+		if !ok || !s.Users.Check(login, pass) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="tab"`)
+			writeError(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+		if r.PathValue("owner") != login {
+			writeError(w, http.StatusForbidden, "user "+login+" can not modify packages of "+r.PathValue("owner"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) push(w http.ResponseWriter, r *http.Request) {
+	if !validPathElements(r.PathValue("distro")) {
+		writeError(w, http.StatusBadRequest, "not valid path")
+		return
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(r.PathValue("sig"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "not valid signature encoding")
+		return
+	}
+
+	tmp, err := os.MkdirTemp(``, "tab-serve-")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	maxsize := s.MaxPackageSize
+	if maxsize == 0 {
+		maxsize = defaultMaxPackageSize
+	}
+	pkgpath, err := receivePackage(tmp, http.MaxBytesReader(w, r.Body, maxsize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = os.WriteFile(pkgpath+".sig", sig, 0o644)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	err = s.verify(pkgpath)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid signature: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.repository(r).Push(&registry.PushParameters{
+		Path:      pkgpath,
+		Signature: sig,
+		Distro:    r.PathValue("distro"),
+	})
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) remove(w http.ResponseWriter, r *http.Request) {
+	elems := []string{r.PathValue("name"), r.PathValue("version")}
+	// Distribution and architecture are present only in scoped route.
+	if r.PathValue("distro") != `` || r.PathValue("arch") != `` {
+		elems = append(elems, r.PathValue("distro"), r.PathValue("arch"))
+	}
+	if !validPathElements(elems...) {
+		writeError(w, http.StatusBadRequest, "not valid path")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _, err := s.repository(r).Remove(&registry.RemoveParameters{
+		Name:    r.PathValue("name"),
+		Version: r.PathValue("version"),
+		Distro:  r.PathValue("distro"),
+		Arch:    r.PathValue("arch"),
+	})
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	if !validPathElements(r.PathValue("distro"), r.PathValue("arch"), r.PathValue("file")) {
+		writeError(w, http.StatusBadRequest, "not valid path")
+		return
+	}
+	repo := s.repository(r)
+	archdir := filepath.Join(repo.Root, r.PathValue("distro"), r.PathValue("arch"))
+
+	// Database is served under any name, since pacman requests it with
+	// name of repository from pacman.conf.
+	file := r.PathValue("file")
+	switch {
+	case strings.Contains(file, ".pkg.tar."):
+	case strings.HasSuffix(file, ".db"), strings.HasSuffix(file, ".db.tar.gz"):
+		file = repo.DatabaseName() + ".db.tar.gz"
+	default:
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	f, err := os.Open(filepath.Join(archdir, file))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	http.ServeContent(w, r, file, info.ModTime(), f)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	// All versions are returned on first page.
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if r.URL.Query().Get("type") != "arch" || page > 1 {
+		writeJSON(w, http.StatusOK, []any{})
+		return
+	}
+	name := r.URL.Query().Get("q")
+	if name != `` && !validPathElements(name) {
+		writeJSON(w, http.StatusOK, []any{})
+		return
+	}
+
+	pkgs, err := s.repository(r).List(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	type version struct {
+		Name      string `json:"name"`
+		Version   string `json:"version"`
+		CreatedAt string `json:"created_at"`
+	}
+	versions := []version{}
+	for _, pkg := range pkgs {
+		versions = append(versions, version{
+			Name:      pkg.Name,
+			Version:   pkg.Version,
+			CreatedAt: pkg.Created.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
+func (s *Server) files(w http.ResponseWriter, r *http.Request) {
+	if !validPathElements(r.PathValue("name"), r.PathValue("version")) {
+		writeError(w, http.StatusBadRequest, "not valid path")
+		return
+	}
+	files, err := s.repository(r).Files(r.PathValue("name"), r.PathValue("version"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(files) == 0 {
+		writeError(w, http.StatusNotFound, "package does not exist")
+		return
+	}
+
+	type file struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+	}
+	list := []file{}
+	for _, f := range files {
+		list = append(list, file{Name: f.Name, Size: f.Size})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) attach(w http.ResponseWriter, r *http.Request) {
+	name, version, file := r.PathValue("name"), r.PathValue("version"), r.PathValue("file")
+	if !validPathElements(name, version, file) {
+		writeError(w, http.StatusBadRequest, "not valid path")
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAttachmentSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.repository(r).Attach(name, version, file, data)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) attachment(w http.ResponseWriter, r *http.Request) {
+	name, version, file := r.PathValue("name"), r.PathValue("version"), r.PathValue("file")
+	if !validPathElements(name, version, file) {
+		writeError(w, http.StatusBadRequest, "not valid path")
+		return
+	}
+	data, err := s.repository(r).Attachment(name, version, file)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+	w.Write(data)
+}
+
+// Get directory repository of owner from request path.
+func (s *Server) repository(r *http.Request) *registry.Dir {
+	owner := r.PathValue("owner")
+	if !validPathElements(owner) {
+		owner = "_"
+	}
+	return &registry.Dir{Root: filepath.Join(s.Root, owner)}
+}
+
+// Verify detached signature of package with GnuPG.
+func (s *Server) verify(pkgpath string) error {
+	cmd := exec.Command("gpg", "--batch", "--verify", pkgpath+".sig", pkgpath)
+	if s.GnuPGHome != `` {
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+s.GnuPGHome)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.New(strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Save uploaded package to directory under its file name, which is formed
+// from package metadata.
+func receivePackage(dir string, body io.Reader) (string, error) {
+	upload := filepath.Join(dir, "upload")
+	f, err := os.Create(upload)
+	if err != nil {
+		return ``, err
+	}
+	_, err = io.Copy(f, body)
+	if err != nil {
+		f.Close()
+		return ``, err
+	}
+	err = f.Close()
+	if err != nil {
+		return ``, err
+	}
+
+	info, err := pacman.ReadPkginfo(upload)
+	if err != nil {
+		return ``, errors.New("not valid package: " + err.Error())
+	}
+	if !validPathElements(info.Name, info.Version, info.Arch) {
+		return ``, errors.New("not valid package metadata")
+	}
+
+	pkgpath := filepath.Join(dir, info.Name+"-"+info.Version+"-"+info.Arch+".pkg.tar.zst")
+	return pkgpath, os.Rename(upload, pkgpath)
+}
+
+// Check that path elements do not escape served directories.
+func validPathElements(elems ...string) bool {
+	for _, e := range elems {
+		if e == `` || e == "." || e == ".." || strings.ContainsAny(e, `/\`) {
+			return false
+		}
+	}
+	return true
+}
+
+// Write registry error with status matching Gitea responses.
+func writeRegistryError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	var rerr *registry.RegistryError
+	if errors.As(err, &rerr) {
+		msg = rerr.Message
+	}
+	switch {
+	case errors.Is(err, registry.ErrVersionExists):
+		writeError(w, http.StatusConflict, msg)
+	case errors.Is(err, registry.ErrPackageNotFound):
+		writeError(w, http.StatusNotFound, msg)
+	default:
+		writeError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// Write error message in Gitea API format.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"message": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Log method, path and response status of each request.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		log.Printf("%s %s %d", r.Method, r.URL.Path, sw.status)
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,72 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package server
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	usersfile := filepath.Join(root, "users")
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	err = os.WriteFile(usersfile, []byte("# team\njohn:"+string(hash)+"\n"), 0o600)
+	assert.NoError(t, err)
+
+	users, err := ReadUsers(usersfile)
+	assert.NoError(t, err)
+	assert.True(t, users.Check("john", "password"))
+	assert.False(t, users.Check("john", "secret"))
+	assert.False(t, users.Check("jane", "password"))
+
+	unsalted := filepath.Join(root, "unsalted")
+	err = os.WriteFile(unsalted, []byte("john:5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8\n"), 0o600)
+	assert.NoError(t, err)
+	_, err = ReadUsers(unsalted)
+	assert.Error(t, err)
+
+	archdir := filepath.Join(root, "john", "archlinux", "x86_64")
+	assert.NoError(t, os.MkdirAll(archdir, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(archdir, "john.db.tar.gz"), []byte("db"), 0o644))
+
+	s := &Server{Root: root, Users: users}
+	cases := []struct {
+		method string
+		path   string
+		login  string
+		status int
+		body   string
+	}{
+		{"DELETE", "/api/packages/john/arch/remove/pkg/1-1", ``, 401, `invalid credentials`},
+		{"DELETE", "/api/packages/john/arch/remove/pkg/1-1", "secret", 401, `invalid credentials`},
+		{"DELETE", "/api/packages/john/arch/remove/pkg/1-1", "password", 404, `not found`},
+		{"DELETE", "/api/packages/team/arch/remove/pkg/1-1", "password", 403, `can not modify`},
+		{"PUT", "/api/packages/team/arch/push/archlinux/c2ln", "password", 403, `can not modify`},
+		{"PUT", "/api/packages/john/arch/push/..%2F..%2Fetc/c2ln", "password", 400, `not valid path`},
+		{"DELETE", "/api/packages/john/arch/..%2F..%2Fetc/pkg/1-1/x86_64", "password", 400, `not valid path`},
+		{"DELETE", "/api/packages/john/arch/archlinux/pkg/..%2F..%2Fx/x86_64", "password", 400, `not valid path`},
+		{"GET", "/api/packages/john/arch/archlinux/x86_64/john.localhost.db", ``, 200, `db`},
+		{"GET", "/api/packages/john/arch/archlinux/x86_64/users", ``, 404, `file not found`},
+		{"GET", "/api/v1/packages/john?type=arch&q=pkg", ``, 200, `[]`},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.login != `` {
+			req.SetBasicAuth("john", c.login)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		assert.Equal(t, c.status, rec.Code, c.method+" "+c.path)
+		assert.True(t, strings.Contains(rec.Body.String(), c.body), rec.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package server
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Users allowed to push and remove packages. Users file contains lines in
+// login:bcrypt(password) format, that is produced by `htpasswd -nB`. Lines
+// starting with # are ignored.
+type Users map[string][]byte
+
+// Hash compared for unknown logins, so response time does not reveal,
+// whether login exists.
+var unknownUserHash, _ = bcrypt.GenerateFromPassword([]byte("unknown"), bcrypt.DefaultCost)
+
+// Read users from file.
+func ReadUsers(file string) (Users, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	users := Users{}
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == `` || strings.HasPrefix(line, "#") {
+			continue
+		}
+		login, hash, ok := strings.Cut(line, ":")
+		if !ok || login == `` {
+			return nil, fmt.Errorf("not valid users file line %d: %s", i+1, file)
+		}
+		_, err = bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return nil, fmt.Errorf("not valid bcrypt hash on users file line %d: %s", i+1, file)
+		}
+		users[login] = []byte(hash)
+	}
+	return users, nil
+}
+
+// Check that login and password match one of users. Hashes are compared by
+// bcrypt in constant time.
+func (u Users) Check(login, password string) bool {
+	hash, ok := u[login]
+	if !ok {
+		bcrypt.CompareHashAndPassword(unknownUserHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
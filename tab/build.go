@@ -31,6 +31,8 @@ type BuildParameters struct {
 	Rmdeps bool `short:"r" long:"rmdeps"`
 	// Do not clean workspace before and after build.
 	Dirty bool `short:"g" long:"dirty"`
+	// Lint built packages, build fails if lint errors are found.
+	Lint bool `short:"l" long:"lint"`
 }
 
 var BuildHelp = `Build, sign and cache package with signature
@@ -41,6 +43,7 @@ options:
 	-s, --syncbuild Syncronize dependencies and build target
 	-r, --rmdeps    Remove installed dependencies after a successful build
 	-g, --dirty     Do not clean workspace before and after build
+	-l, --lint      Check built packages for packaging problems
 	-a, --aur       Build targets from AUR git repositories (aur.archlinux.org)
 
 usage: tab {-B --build} [options] <git/repository(s)>`
@@ -49,6 +52,15 @@ usage: tab {-B --build} [options] <git/repository(s)>`
 func Build(args []string, prms ...BuildParameters) error {
 	p := getParameters(prms)
 
+	if p.Syncbuild {
+		return recordTransaction("build", args, func() error {
+			return build(p, args)
+		})
+	}
+	return build(p, args)
+}
+
+func build(p *BuildParameters, args []string) error {
 	msgs.Amsg(os.Stdout, "Building packages")
 
 	msgs.Smsg(os.Stdout, "Running GnuPG check", 1, 2)
@@ -63,7 +75,13 @@ func Build(args []string, prms ...BuildParameters) error {
 		return err
 	}
 
+	signer, err := signingFingerprint()
+	if err != nil {
+		return err
+	}
+
 	var builddirs []string
+	var sources []string
 	var buildcurrdir bool
 
 	if len(args) == 0 {
@@ -72,6 +90,7 @@ func Build(args []string, prms ...BuildParameters) error {
 			return err
 		}
 		builddirs = append(args, currdir)
+		sources = append(sources, ``)
 		buildcurrdir = true
 	}
 
@@ -81,11 +100,12 @@ func Build(args []string, prms ...BuildParameters) error {
 			return err
 		}
 		builddirs = append(builddirs, dir)
+		sources = append(sources, "https://"+arg)
 	}
 
-	for _, dir := range builddirs {
+	for i, dir := range builddirs {
 		msgs.Amsg(os.Stdout, "Building package with makepkg")
-		err = pacman.Makepkg(pacman.MakepkgParameters{
+		mp := pacman.MakepkgParameters{
 			Sign:       true,
 			Dir:        dir,
 			Stdout:     os.Stdout,
@@ -99,11 +119,26 @@ func Build(args []string, prms ...BuildParameters) error {
 			SyncDeps:   p.Syncbuild,
 			Needed:     !p.Syncbuild,
 			NoConfirm:  p.Quick,
-		})
+		}
+		err = pacman.Makepkg(mp)
 		if err != nil {
 			return errors.Join(err)
 		}
 
+		if p.Lint {
+			msgs.Amsg(os.Stdout, "Linting packages")
+			err = lintDir(dir)
+			if err != nil {
+				return err
+			}
+		}
+
+		msgs.Amsg(os.Stdout, "Writing build provenance")
+		err = WriteProvenance(dir, sources[i], signer, mp.Args())
+		if err != nil {
+			return err
+		}
+
 		msgs.Amsg(os.Stdout, "Moving package to cache")
 		err = CachePackage(dir, p.Dir)
 		if err != nil {
@@ -149,24 +184,79 @@ func ValidatePackager() error {
 	if err != nil {
 		return err
 	}
-	f, err := os.ReadFile("/etc/makepkg.conf")
+	confPackager, err := makepkgPackager()
 	if err != nil {
 		return err
 	}
-	splt := strings.Split(string(f), "\nPACKAGER=\"")
-	if len(splt) != 2 {
+	if confPackager == `` {
 		return fmt.Errorf(msgs.ErrNoPackager, keySigner)
 	}
-	confPackager := strings.Split(splt[1], "\"\n")[0]
 	if confPackager != keySigner {
 		return fmt.Errorf(msgs.ErrPackagerMissmatch, keySigner, confPackager)
 	}
 	return nil
 }
 
+// Read PACKAGER variable from /etc/makepkg.conf, empty if it is not set.
+func makepkgPackager() (string, error) {
+	return makepkgVariable("PACKAGER")
+}
+
+// Path to makepkg configuration, variables of makepkg are read from it.
+var makepkgConf = "/etc/makepkg.conf"
+
+// Read variable from makepkg.conf, empty if it is not set or commented.
+func makepkgVariable(name string) (string, error) {
+	f, err := os.ReadFile(makepkgConf)
+	if err != nil {
+		return ``, err
+	}
+	var value string
+	for _, line := range strings.Split(string(f), "\n") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(line), name+"=")
+		if ok {
+			value = strings.Trim(v, `"'`)
+		}
+	}
+	return value, nil
+}
+
+// Get fingerprint of key, that makepkg signs packages with: GPGKEY from
+// /etc/makepkg.conf if set, otherwise packager identity, which is validated
+// to match GnuPG identity before build.
+func signingFingerprint() (string, error) {
+	key, err := makepkgVariable("GPGKEY")
+	if err != nil {
+		return ``, err
+	}
+	if key == `` {
+		key, err = makepkgPackager()
+		if err != nil {
+			return ``, err
+		}
+	}
+	if key == `` {
+		key, err = GnuPGidentity()
+		if err != nil {
+			return ``, err
+		}
+	}
+	return GnuPGfingerprint(key)
+}
+
 // Returns name and email from GnuPG. Error, if did not succeed.
 func GnuPGidentity() (string, error) {
-	cmd := exec.Command("gpg", "-K")
+	return GnuPGkeyIdentity(``)
+}
+
+// Returns name and email for specific GnuPG key, first ultimate identity is
+// used if key is empty.
+func GnuPGkeyIdentity(key string) (string, error) {
+	gpgargs := []string{"-K"}
+	if key != `` {
+		gpgargs = append(gpgargs, key)
+	}
+	cmd := exec.Command("gpg", gpgargs...)
 	var b bytes.Buffer
 	cmd.Stdout = &b
 	cmd.Stderr = &b
@@ -181,7 +271,8 @@ func GnuPGidentity() (string, error) {
 	return strings.Split(splt[1], "\n")[0], nil
 }
 
-// Move package and signature files to cache location defined by user.
+// Move package, signature and provenance files to cache location defined by
+// user.
 func CachePackage(src, dst string) error {
 	entries, err := os.ReadDir(src)
 	if err != nil {
@@ -189,7 +280,8 @@ func CachePackage(src, dst string) error {
 	}
 	for _, de := range entries {
 		if strings.HasSuffix(de.Name(), ".pkg.tar.zst") ||
-			strings.HasSuffix(de.Name(), ".pkg.tar.zst.sig") {
+			strings.HasSuffix(de.Name(), ".pkg.tar.zst.sig") ||
+			strings.HasSuffix(de.Name(), ".pkg.tar.zst"+provenanceSuffix) {
 			err = call(process.Command(&process.Params{
 				Sudo:    true,
 				Command: "mv",
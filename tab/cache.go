@@ -0,0 +1,184 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/process"
+)
+
+// Package file in cache with files, that belong to it.
+type cachedPackage struct {
+	Name    string
+	Version string
+	Arch    string
+	// Package file together with signature and provenance, if they exist.
+	Files []string
+	// Total size of package files.
+	Size int64
+}
+
+// Remove old package versions from cache. For each package name and
+// architecture newest versions are kept, installed versions are never removed.
+func pruneCache(p *RemoveParameters, out io.Writer) error {
+	installed, err := installedVersions(systemDBPath())
+	if err != nil {
+		return err
+	}
+	return pruneCacheVersions(p, installed, out)
+}
+
+// Remove old package versions from cache, versions of installed packages are
+// provided by name.
+func pruneCacheVersions(p *RemoveParameters, installed map[string]string, out io.Writer) error {
+	keep, err := p.keep(3)
+	if err != nil {
+		return err
+	}
+
+	msgs.Amsg(out, "Scanning package cache "+p.Cachedir)
+	cached, err := readCache(p.Cachedir)
+	if err != nil {
+		return err
+	}
+
+	groups := map[string][]cachedPackage{}
+	var keys []string
+	for _, pkg := range cached {
+		key := pkg.Name + "-" + pkg.Arch
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], pkg)
+	}
+	slices.Sort(keys)
+
+	var pruned []cachedPackage
+	for _, key := range keys {
+		pkgs := groups[key]
+		version, isInstalled := installed[pkgs[0].Name]
+		if p.Uninstalled && isInstalled {
+			continue
+		}
+		slices.SortFunc(pkgs, func(a, b cachedPackage) int {
+			return pacman.Vercmp(b.Version, a.Version)
+		})
+		for i, pkg := range pkgs {
+			if i < keep || (isInstalled && pkg.Version == version) {
+				continue
+			}
+			pruned = append(pruned, pkg)
+		}
+	}
+
+	if len(pruned) == 0 {
+		msgs.Amsg(out, "Nothing to prune in package cache")
+		return nil
+	}
+
+	var freed int64
+	var rows [][]string
+	for _, pkg := range pruned {
+		freed += pkg.Size
+		rows = append(rows, []string{pkg.Name, pkg.Version, pkg.Arch, formatSize(pkg.Size)})
+	}
+	msgs.Amsg(out, "Cache deletion plan")
+	msgs.Table(out, []string{"package", "version", "arch", "size"}, rows)
+
+	if p.Print {
+		msgs.Amsg(out, "Pruning would free "+formatSize(freed))
+		return nil
+	}
+	if !p.Quick && !msgs.AskForConfirmation(os.Stdin, out, "Remove packages from cache") {
+		return errors.New("cache pruning cancelled")
+	}
+
+	var files []string
+	for _, pkg := range pruned {
+		files = append(files, pkg.Files...)
+	}
+	err = removeCacheFiles(files)
+	if err != nil {
+		return err
+	}
+	msgs.Amsg(out, "Freed "+formatSize(freed))
+	return nil
+}
+
+// Read packages stored in cache directory, signatures and provenance
+// documents are grouped with their packages.
+func readCache(dir string) ([]cachedPackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []cachedPackage
+	for _, e := range entries {
+		if e.IsDir() || !isPackageFile(e.Name()) {
+			continue
+		}
+		parsed, err := pacman.ParsePackageFile(e.Name())
+		if err != nil {
+			continue
+		}
+		pkg := cachedPackage{Name: parsed.Name, Version: parsed.Version, Arch: parsed.Arch}
+		for _, file := range []string{e.Name(), e.Name() + ".sig", e.Name() + provenanceSuffix} {
+			info, err := os.Stat(filepath.Join(dir, file))
+			if err != nil {
+				continue
+			}
+			pkg.Files = append(pkg.Files, filepath.Join(dir, file))
+			pkg.Size += info.Size()
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// Compression extensions of package archives, that are supported by pacman.
+var packageExtensions = []string{".zst", ".xz", ".gz", ".bz2", ".lz4", ".lzo", ".lrz", ".Z", ""}
+
+// Check whether file name is package archive.
+func isPackageFile(filename string) bool {
+	for _, ext := range packageExtensions {
+		if strings.HasSuffix(filename, ".pkg.tar"+ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove files from cache, files are removed with elevated privileges if
+// current user is not allowed to remove them.
+func removeCacheFiles(files []string) error {
+	var denied []string
+	for _, file := range files {
+		err := os.Remove(file)
+		if errors.Is(err, os.ErrPermission) {
+			denied = append(denied, file)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	return call(process.Command(&process.Params{
+		Sudo:    true,
+		Command: "rm",
+		Args:    append([]string{"-f"}, denied...),
+	}))
+}
@@ -0,0 +1,90 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// Create cache directory with provided files and return its path.
+func writeTestCache(t *testing.T, files []string) string {
+	dir := t.TempDir()
+	for _, file := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(file), 0o644))
+	}
+	return dir
+}
+
+// List file names in directory.
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestPruneCache(t *testing.T) {
+	files := []string{
+		"nano-7.0-1-x86_64.pkg.tar.zst",
+		"nano-7.0-1-x86_64.pkg.tar.zst.sig",
+		"nano-7.0-1-x86_64.pkg.tar.zst" + provenanceSuffix,
+		"nano-7.1-1-x86_64.pkg.tar.zst",
+		"nano-7.1-1-x86_64.pkg.tar.zst.sig",
+		"nano-7.2-1-x86_64.pkg.tar.zst",
+		"nano-7.2-1-x86_64.pkg.tar.zst.sig",
+		"nano-7.1-1-aarch64.pkg.tar.zst",
+		"nano-7.2-1-aarch64.pkg.tar.zst",
+		"tool-1.10-1-any.pkg.tar.xz",
+		"tool-1.9-1-any.pkg.tar.xz",
+		"tool-1.9-1-any.pkg.tar.xz.sig",
+	}
+	installed := map[string]string{"nano": "7.0-1"}
+	one, zero := 1, 0
+
+	dir := writeTestCache(t, files)
+	err := pruneCacheVersions(&RemoveParameters{Cachedir: dir, Keep: &one, Print: true, Quick: true}, installed, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, listDir(t, writeTestCache(t, files)), listDir(t, dir))
+
+	err = pruneCacheVersions(&RemoveParameters{Cachedir: dir, Keep: &one, Quick: true}, installed, io.Discard)
+	assert.NoError(t, err)
+	// Newest version is kept for each name and architecture, installed
+	// version is kept with its signature and provenance.
+	assert.Equal(t, []string{
+		"nano-7.0-1-x86_64.pkg.tar.zst",
+		"nano-7.0-1-x86_64.pkg.tar.zst" + provenanceSuffix,
+		"nano-7.0-1-x86_64.pkg.tar.zst.sig",
+		"nano-7.2-1-aarch64.pkg.tar.zst",
+		"nano-7.2-1-x86_64.pkg.tar.zst",
+		"nano-7.2-1-x86_64.pkg.tar.zst.sig",
+		"tool-1.10-1-any.pkg.tar.xz",
+	}, listDir(t, dir))
+
+	dir = writeTestCache(t, files)
+	err = pruneCacheVersions(&RemoveParameters{Cachedir: dir, Keep: &zero, Uninstalled: true, Quick: true}, installed, io.Discard)
+	assert.NoError(t, err)
+	// Only packages, that are not installed, are pruned.
+	assert.Equal(t, listDir(t, writeTestCache(t, files[:9])), listDir(t, dir))
+
+	dir = writeTestCache(t, append(files, "tool-1.8-1-any.pkg.tar.xz", "tool-1.7-1-any.pkg.tar.xz"))
+	err = pruneCacheVersions(&RemoveParameters{Cachedir: dir, Quick: true}, installed, io.Discard)
+	assert.NoError(t, err)
+	// Three newest versions are kept when --keep is not set.
+	assert.Equal(t, listDir(t, writeTestCache(t, append(files, "tool-1.8-1-any.pkg.tar.xz"))), listDir(t, dir))
+
+	negative := -1
+	err = pruneCacheVersions(&RemoveParameters{Cachedir: dir, Keep: &negative}, installed, io.Discard)
+	assert.EqualError(t, err, "amount of kept versions can not be negative")
+}
@@ -0,0 +1,78 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"strings"
+
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
+)
+
+// Read pacman database from registry and list package entries in
+// name-version format.
+func remoteDatabaseEntries(reg registry.Registry, distro, arch string) ([]string, error) {
+	db, err := reg.Database(distro, arch)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	r, err := pacman.Decompress(db)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry, _, _ := strings.Cut(hdr.Name, "/")
+		if len(entries) == 0 || entries[len(entries)-1] != entry {
+			entries = append(entries, entry)
+		}
+	}
+}
+
+// Check that package is present in databases for each architecture, returns
+// list of architectures, where package is missing.
+func missingArchitectures(p *PushParameters, md PackageMetadata, version string) ([]string, error) {
+	var missing []string
+	for _, arch := range p.Archs {
+		entries, err := remoteDatabaseEntries(md.Registry, p.Distro, arch)
+		if err != nil {
+			return nil, err
+		}
+		var found bool
+		for _, entry := range entries {
+			if entry == md.Name+"-"+version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, arch)
+		}
+	}
+	return missing, nil
+}
+
+// Get address and owner of registry for result output.
+func registryFields(reg registry.Registry) (string, string) {
+	if g, ok := reg.(*registry.Gitea); ok {
+		return g.Addr, g.Owner
+	}
+	return reg.String(), ``
+}
@@ -0,0 +1,164 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/registry"
+)
+
+// Export public GPG key, which can be added to gitea/gitlab/github. Key is
+// written to stdout or file, and can be uploaded to registry account.
+func Export(p *PushParameters) error {
+	key := p.Key
+	if key == `` {
+		ident, err := GnuPGidentity()
+		if err != nil {
+			return err
+		}
+		key = strings.Replace(strings.Split(ident, " <")[1], ">", "", 1)
+	}
+
+	var armor bytes.Buffer
+	var errbuf bytes.Buffer
+	cmd := exec.Command("gpg", "--armor", "--export", key)
+	cmd.Stdout = &armor
+	cmd.Stderr = &errbuf
+	err := cmd.Run()
+	if err != nil {
+		return errors.New("unable to export gpg key: " + errbuf.String())
+	}
+	if armor.Len() == 0 {
+		return errors.New("no public gpg key found for: " + key)
+	}
+
+	// Key is written to file before upload, so it is kept even if upload
+	// fails. Stdout is used only if key is neither written nor uploaded.
+	if p.Output != `` {
+		err = os.WriteFile(p.Output, armor.Bytes(), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	if p.Upload != `` {
+		return uploadKey(p, key, armor.String())
+	}
+	if p.Output == `` {
+		_, err = os.Stdout.Write(armor.Bytes())
+	}
+	return err
+}
+
+// Get fingerprint of primary GnuPG key.
+func GnuPGfingerprint(key string) (string, error) {
+	var b bytes.Buffer
+	cmd := exec.Command("gpg", "--with-colons", "--fingerprint", key)
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err := cmd.Run()
+	if err != nil {
+		return ``, errors.New("unable to get gpg fingerprint: " + b.String())
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "fpr:") {
+			splt := strings.Split(line, ":")
+			if len(splt) > 9 {
+				return splt[9], nil
+			}
+		}
+	}
+	return ``, errors.New("no gpg fingerprint found for: " + key)
+}
+
+// GPG key registered in registry user account.
+type registryKey struct {
+	KeyID string `json:"key_id"`
+}
+
+// Upload armored public key to registry user account, upload is skipped if
+// key is already registered.
+func uploadKey(p *PushParameters, key, armor string) error {
+	reg, err := registry.Open(p.Upload, p.Insecure)
+	if err != nil {
+		return err
+	}
+	gitea, ok := reg.(*registry.Gitea)
+	if !ok {
+		return errors.New("key upload is not supported by registry: " + p.Upload)
+	}
+
+	fingerprint, err := GnuPGfingerprint(key)
+	if err != nil {
+		return err
+	}
+
+	msgs.Amsg(os.Stdout, "Uploading GPG key "+fingerprint)
+
+	url := gitea.Protocol + "://" + gitea.Addr + "/api/v1/user/gpg_keys"
+
+	keys, err := registeredKeys(gitea, url)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if strings.HasSuffix(strings.ToUpper(fingerprint), strings.ToUpper(k.KeyID)) {
+			msgs.Smsg(os.Stdout, "Key is already registered, skipping", 1, 1)
+			return nil
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{"armored_public_key": armor})
+	if err != nil {
+		return err
+	}
+	resp, err := gitea.Request(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	msgs.Smsg(os.Stdout, "Key uploaded", 1, 1)
+	return nil
+}
+
+// List all GPG keys registered in registry user account, keys are read page
+// by page.
+func registeredKeys(gitea *registry.Gitea, url string) ([]registryKey, error) {
+	var keys []registryKey
+	for page := 1; ; page++ {
+		resp, err := gitea.Request(func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, fmt.Sprintf("%s?page=%d&limit=50", url, page), nil)
+		}, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+
+		var found []registryKey
+		err = json.NewDecoder(resp.Body).Decode(&found)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, found...)
+		if len(found) < 50 {
+			return keys, nil
+		}
+	}
+}
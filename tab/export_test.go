@@ -0,0 +1,48 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"ion.lc/core/tab/registry"
+)
+
+func TestRegisteredKeys(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var keys []registryKey
+		switch r.URL.Query().Get("page") {
+		case "1":
+			for i := 0; i < 50; i++ {
+				keys = append(keys, registryKey{KeyID: fmt.Sprintf("%016X", i)})
+			}
+		case "2":
+			keys = append(keys, registryKey{KeyID: "ABCDEF0123456789"})
+		}
+		json.NewEncoder(w).Encode(keys)
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	err := os.WriteFile(filepath.Join(home, ".git-credentials"), []byte("http://john:password@"+addr+"\n"), 0o600)
+	assert.NoError(t, err)
+
+	gitea := &registry.Gitea{Protocol: "http", Addr: addr, Owner: "john"}
+	keys, err := registeredKeys(gitea, srv.URL+"/api/v1/user/gpg_keys")
+	assert.NoError(t, err)
+	assert.Equal(t, 51, len(keys))
+	assert.Equal(t, "ABCDEF0123456789", keys[50].KeyID)
+}
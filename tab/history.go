@@ -0,0 +1,486 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ion.lc/core/tab/alpm"
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+)
+
+type HistoryParameters struct {
+	// Write transactions as JSON stream.
+	JSON bool `short:"j" long:"json"`
+}
+
+var HistoryHelp = `List recorded transactions
+
+options:
+	-j, --json Write transactions as JSON stream to stdout
+
+usage: tab --history [options]`
+
+type RollbackParameters struct {
+	// Do not ask for any confirmation.
+	Quick bool `short:"q" long:"quick"`
+	// Package cache directory, where previous versions are searched.
+	Cachedir string `long:"cachedir" default:"/var/cache/pacman/pkg"`
+}
+
+var RollbackHelp = `Undo recorded transaction
+
+options:
+	-q, --quick      Do not ask for any confirmation
+	    --cachedir   Package cache directory (default /var/cache/pacman/pkg)
+
+Previous package versions are installed from package cache, missing versions
+are downloaded from registry they were installed from.
+
+usage: tab --rollback [options] <transaction-id>`
+
+// Transaction, that changed installed packages or pacman.conf.
+type Transaction struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Args      []string  `json:"args"`
+	Added     []Change  `json:"added,omitempty"`
+	Removed   []Change  `json:"removed,omitempty"`
+	Upgraded  []Change  `json:"upgraded,omitempty"`
+	// Content of pacman.conf before transaction, if it was changed.
+	PrevConf *string `json:"prev_conf,omitempty"`
+}
+
+// Change of installed package version.
+type Change struct {
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+	OldVersion string `json:"old_version,omitempty"`
+	// Sync database, that previous version was available from.
+	Repository string `json:"repository,omitempty"`
+	// Previous version was installed as dependency of other package.
+	Dependency bool `json:"dependency,omitempty"`
+}
+
+// State of system before transaction.
+type systemState struct {
+	installed map[string]string
+	// Packages installed as dependencies.
+	deps  map[string]bool
+	repos map[string]string
+	conf  string
+}
+
+// Run operation and record changes of installed packages and pacman.conf to
+// history. Operation is executed even if system state can not be read.
+func recordTransaction(op string, args []string, fn func() error) error {
+	before, serr := readSystemState(true)
+	err := fn()
+	if serr != nil {
+		return err
+	}
+	after, serr := readSystemState(false)
+	if serr != nil {
+		return err
+	}
+
+	tx := diffStates(before, after)
+	if len(tx.Added)+len(tx.Removed)+len(tx.Upgraded) == 0 && tx.PrevConf == nil {
+		return err
+	}
+	tx.Time = time.Now()
+	tx.Operation = op
+	tx.Args = args
+	return errors.Join(err, appendHistory(&tx))
+}
+
+// Read installed packages from local database and pacman.conf. Repositories
+// of packages are read from sync databases only if repos is set, since they
+// are needed only for state before transaction.
+func readSystemState(repos bool) (*systemState, error) {
+	conf, err := os.ReadFile("/etc/pacman.conf")
+	if err != nil {
+		return nil, err
+	}
+	dbpath := confDBPath(string(conf))
+	local, err := alpm.ReadLocal(dbpath, false)
+	if err != nil {
+		return nil, err
+	}
+	state := &systemState{
+		installed: map[string]string{},
+		deps:      map[string]bool{},
+		repos:     map[string]string{},
+		conf:      string(conf),
+	}
+	for _, pkg := range local {
+		state.installed[pkg.Name] = pkg.Version
+		state.deps[pkg.Name] = pkg.Reason == alpm.ReasonDepend
+	}
+	if repos {
+		state.repos = syncRepositories(dbpath, string(conf))
+	}
+	return state, nil
+}
+
+// Get versions of installed packages from local database.
+func installedVersions(dbpath string) (map[string]string, error) {
+	local, err := alpm.ReadLocal(dbpath, false)
+	if err != nil {
+		return nil, err
+	}
+	installed := map[string]string{}
+	for _, pkg := range local {
+		installed[pkg.Name] = pkg.Version
+	}
+	return installed, nil
+}
+
+// Get repositories of packages from sync databases, package belongs to first
+// repository in pacman.conf, that contains it. Unreadable databases are
+// skipped.
+func syncRepositories(dbpath, conf string) map[string]string {
+	repos := map[string]string{}
+	for _, repo := range confRepositories(conf) {
+		pkgs, err := alpm.ReadSyncFile(filepath.Join(dbpath, "sync", repo+".db"))
+		if err != nil {
+			continue
+		}
+		for _, pkg := range pkgs {
+			if _, ok := repos[pkg.Name]; !ok {
+				repos[pkg.Name] = repo
+			}
+		}
+	}
+	return repos
+}
+
+// Compare system states and form transaction from differences.
+func diffStates(before, after *systemState) Transaction {
+	var tx Transaction
+	for name, version := range after.installed {
+		old, ok := before.installed[name]
+		switch {
+		case !ok:
+			tx.Added = append(tx.Added, Change{Name: name, Version: version})
+		case old != version:
+			tx.Upgraded = append(tx.Upgraded, Change{
+				Name:       name,
+				Version:    version,
+				OldVersion: old,
+				Repository: before.repos[name],
+				Dependency: before.deps[name],
+			})
+		}
+	}
+	for name, version := range before.installed {
+		if _, ok := after.installed[name]; !ok {
+			tx.Removed = append(tx.Removed, Change{
+				Name:       name,
+				OldVersion: version,
+				Repository: before.repos[name],
+				Dependency: before.deps[name],
+			})
+		}
+	}
+	for _, changes := range [][]Change{tx.Added, tx.Removed, tx.Upgraded} {
+		slices.SortFunc(changes, func(a, b Change) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	if before.conf != after.conf {
+		tx.PrevConf = &before.conf
+	}
+	return tx
+}
+
+// Path to file, where transaction history is stored.
+func historyFile() (string, error) {
+	state := os.Getenv("XDG_STATE_HOME")
+	if state == `` {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ``, err
+		}
+		state = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(state, "tab", "history.jsonl"), nil
+}
+
+// Read all recorded transactions.
+func readHistory() ([]Transaction, error) {
+	path, err := historyFile()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var txs []Transaction
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var tx Transaction
+		err = json.Unmarshal(scanner.Bytes(), &tx)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, scanner.Err()
+}
+
+// Append transaction to history, transaction is assigned next ID.
+func appendHistory(tx *Transaction) error {
+	txs, err := readHistory()
+	if err != nil {
+		return err
+	}
+	tx.ID = 1
+	if len(txs) > 0 {
+		tx.ID = txs[len(txs)-1].ID + 1
+	}
+
+	path, err := historyFile()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	return errors.Join(err, f.Close())
+}
+
+// List recorded transactions.
+func History(args []string, prms ...HistoryParameters) error {
+	p := getParameters(prms)
+
+	txs, err := readHistory()
+	if err != nil {
+		return err
+	}
+
+	if p.JSON {
+		for _, tx := range txs {
+			err = writeJSON(os.Stdout, tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var rows [][]string
+	for _, tx := range txs {
+		changes := []string{}
+		for _, c := range tx.Added {
+			changes = append(changes, "+"+c.Name+" "+c.Version)
+		}
+		for _, c := range tx.Removed {
+			changes = append(changes, "-"+c.Name+" "+c.OldVersion)
+		}
+		for _, c := range tx.Upgraded {
+			changes = append(changes, "~"+c.Name+" "+c.OldVersion+" -> "+c.Version)
+		}
+		if tx.PrevConf != nil {
+			changes = append(changes, "pacman.conf")
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(tx.ID),
+			tx.Time.Format("2006-01-02 15:04"),
+			tx.Operation + " " + strings.Join(tx.Args, " "),
+			strings.Join(changes, ", "),
+		})
+	}
+	msgs.Table(os.Stdout, []string{"id", "time", "operation", "changes"}, rows)
+	return nil
+}
+
+// Undo recorded transaction. Added packages are removed, removed and
+// upgraded packages are reinstalled in previous versions and pacman.conf is
+// restored. Rollback is recorded as a new transaction.
+func Rollback(args []string, prms ...RollbackParameters) error {
+	p := getParameters(prms)
+
+	if len(args) != 1 {
+		return errors.New("specify single transaction id to rollback")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errors.New("not valid transaction id: " + args[0])
+	}
+	txs, err := readHistory()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(txs, func(tx Transaction) bool { return tx.ID == id })
+	if i < 0 {
+		return fmt.Errorf("transaction %d not found in history", id)
+	}
+	tx := txs[i]
+
+	if !p.Quick && !msgs.AskForConfirmation(os.Stdin, os.Stdout, fmt.Sprintf("Rollback transaction %d (%s)", tx.ID, tx.Operation)) {
+		return errors.New("rollback cancelled")
+	}
+
+	return recordTransaction("rollback", args, func() error {
+		return rollback(p, tx)
+	})
+}
+
+func rollback(p *RollbackParameters, tx Transaction) error {
+	if len(tx.Added) > 0 {
+		msgs.Amsg(os.Stdout, "Removing added packages")
+		var pkgs []string
+		for _, c := range tx.Added {
+			pkgs = append(pkgs, c.Name)
+		}
+		err := pacman.RemoveList(pkgs, pacman.RemoveParameters{
+			Sudo:      true,
+			NoConfirm: p.Quick,
+			Stdout:    os.Stdout,
+			Stderr:    os.Stderr,
+			Stdin:     os.Stdin,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	previous := append(slices.Clone(tx.Removed), tx.Upgraded...)
+	if len(previous) > 0 {
+		msgs.Amsg(os.Stdout, "Preparing previous package versions")
+		tmp, err := os.MkdirTemp(``, "tab-rollback-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		cached, err := readCache(p.Cachedir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		conf, err := os.ReadFile("/etc/pacman.conf")
+		if err != nil {
+			return err
+		}
+
+		var files []string
+		for i, c := range previous {
+			msgs.Smsg(os.Stdout, "Searching for "+c.Name+" "+c.OldVersion, i+1, len(previous))
+			file, err := previousPackage(cached, string(conf), tmp, c)
+			if err != nil {
+				return err
+			}
+			files = append(files, file)
+		}
+
+		msgs.Amsg(os.Stdout, "Installing previous package versions")
+		err = pacman.UpgradeList(files, pacman.UpgradeParameters{
+			Sudo:      true,
+			NoConfirm: p.Quick,
+			Stdout:    os.Stdout,
+			Stderr:    os.Stderr,
+			Stdin:     os.Stdin,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Reinstalled packages are marked explicit by pacman, install
+		// reason of dependencies is restored, so they can become orphans.
+		var deps []string
+		for _, c := range previous {
+			if c.Dependency {
+				deps = append(deps, c.Name)
+			}
+		}
+		if len(deps) > 0 {
+			msgs.Amsg(os.Stdout, "Restoring install reason of dependencies")
+			err = pacman.DatabaseList(deps, pacman.DatabaseParameters{
+				Sudo:   true,
+				AsDeps: true,
+				Stdout: os.Stdout,
+				Stderr: os.Stderr,
+				Stdin:  os.Stdin,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if tx.PrevConf != nil {
+		msgs.Amsg(os.Stdout, "Restoring pacman.conf")
+		return writeconf(*tx.PrevConf)
+	}
+	return nil
+}
+
+// Find previous version of package in cache, or download it with signature
+// from servers of repository it was installed from. All package compression
+// formats are tried, since old versions can be compressed differently.
+func previousPackage(cached []cachedPackage, conf, tmp string, c Change) (string, error) {
+	for _, pkg := range cached {
+		if pkg.Name == c.Name && pkg.Version == c.OldVersion {
+			return pkg.Files[0], nil
+		}
+	}
+
+	if c.Repository == `` {
+		return ``, fmt.Errorf("%s %s is not in cache and its repository is unknown", c.Name, c.OldVersion)
+	}
+	var errs []error
+	for _, server := range confServers(conf, c.Repository) {
+		link := strings.NewReplacer("$repo", c.Repository, "$arch", machineArch()).Replace(server)
+		for _, arch := range []string{machineArch(), "any"} {
+			for _, ext := range packageExtensions {
+				filename := c.Name + "-" + c.OldVersion + "-" + arch + ".pkg.tar" + ext
+				dst := filepath.Join(tmp, filename)
+				err := downloadFile(link+"/"+filename, dst)
+				if err == nil {
+					err = downloadFile(link+"/"+filename+".sig", dst+".sig")
+				}
+				if err == nil {
+					return dst, nil
+				}
+				if !errors.Is(err, errFileNotFound) {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return ``, fmt.Errorf("%s %s not found in cache or in %s: %w", c.Name, c.OldVersion, c.Repository, errors.Join(errs...))
+	}
+	return ``, fmt.Errorf("%s %s not found in cache or in %s", c.Name, c.OldVersion, c.Repository)
+}
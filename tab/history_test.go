@@ -0,0 +1,65 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestDiffStates(t *testing.T) {
+	before := &systemState{
+		installed: map[string]string{"nano": "7.2-1", "vim": "9.0-1", "git": "2.40-1"},
+		deps:      map[string]bool{"vim": true},
+		repos:     map[string]string{"nano": "core", "vim": "extra", "git": "extra"},
+		conf:      "[core]\n",
+	}
+	after := &systemState{
+		installed: map[string]string{"nano": "7.2-1", "git": "2.41-1", "blender": "4.0-1"},
+		conf:      "[core]\n[owner.example.com]\n",
+	}
+
+	tx := diffStates(before, after)
+
+	assert.Equal(t, []Change{{Name: "blender", Version: "4.0-1"}}, tx.Added)
+	assert.Equal(t, []Change{{Name: "vim", OldVersion: "9.0-1", Repository: "extra", Dependency: true}}, tx.Removed)
+	assert.Equal(t, []Change{{Name: "git", Version: "2.41-1", OldVersion: "2.40-1", Repository: "extra"}}, tx.Upgraded)
+	assert.NotZero(t, tx.PrevConf)
+	assert.Equal(t, before.conf, *tx.PrevConf)
+}
+
+func TestConfServers(t *testing.T) {
+	conf := "[options]\nArchitecture = auto\n\n[core]\nSigLevel = Required\n\n" +
+		"[owner.example.com]\nSigLevel = Optional\nServer = https://example.com/api/packages/owner/arch/archlinux/x86_64/\n"
+
+	assert.Equal(t, []string{"https://example.com/api/packages/owner/arch/archlinux/x86_64"}, confServers(conf, "owner.example.com"))
+	assert.Zero(t, confServers(conf, "core"))
+}
+
+func TestPreviousPackage(t *testing.T) {
+	repo := t.TempDir()
+	tmp := t.TempDir()
+	filename := "nano-7.2-1-" + machineArch() + ".pkg.tar.xz"
+	assert.NoError(t, os.WriteFile(filepath.Join(repo, filename), []byte("package"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(repo, filename+".sig"), []byte("signature"), 0o644))
+	conf := "[core]\nServer = file://" + repo + "\n"
+
+	cached := []cachedPackage{{Name: "vim", Version: "9.0-1", Arch: "any", Files: []string{"/cache/vim-9.0-1-any.pkg.tar.zst"}}}
+	file, err := previousPackage(cached, conf, tmp, Change{Name: "vim", OldVersion: "9.0-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/cache/vim-9.0-1-any.pkg.tar.zst", file)
+
+	file, err = previousPackage(cached, conf, tmp, Change{Name: "nano", OldVersion: "7.2-1", Repository: "core"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmp, filename), file)
+	_, err = os.Stat(file + ".sig")
+	assert.NoError(t, err)
+
+	_, err = previousPackage(cached, conf, tmp, Change{Name: "nano", OldVersion: "7.1-1", Repository: "core"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"ion.lc/core/tab/pacman"
+)
+
+// Severity of package lint issue.
+type Severity string
+
+const (
+	// Package should not be published, push is blocked.
+	SeverityError Severity = "error"
+	// Package can be published, but should be fixed.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem found in package archive.
+type LintIssue struct {
+	Severity Severity
+	Message  string
+}
+
+// Directories, that packages should never install files to.
+var lintForbiddenDirs = []string{"usr/local/", "home/"}
+
+// Check package archive for common packaging problems.
+func LintPackage(pkgpath string) ([]LintIssue, error) {
+	info, err := pacman.ReadPkginfo(pkgpath)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []LintIssue
+	add := func(s Severity, format string, args ...any) {
+		issues = append(issues, LintIssue{Severity: s, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var buildinfo bool
+	var elfs []string
+	err = pacman.WalkPackage(pkgpath, func(hdr *tar.Header, r io.Reader) error {
+		name := strings.TrimPrefix(hdr.Name, "./")
+		switch {
+		case name == ".BUILDINFO":
+			buildinfo = true
+			return nil
+		case strings.HasPrefix(name, "."):
+			return nil
+		}
+
+		for _, dir := range lintForbiddenDirs {
+			if strings.HasPrefix(name, dir) && name != dir {
+				add(SeverityError, "file is installed under /%s: /%s", dir, name)
+				break
+			}
+		}
+
+		// Directories with sticky bit, such as /tmp, can be world-writable.
+		if hdr.Typeflag != tar.TypeSymlink && hdr.Mode&0o002 != 0 &&
+			!(hdr.Typeflag == tar.TypeDir && hdr.Mode&0o1000 != 0) {
+			add(SeverityError, "file is world-writable: /%s", name)
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			magic := make([]byte, 4)
+			n, _ := io.ReadFull(r, magic)
+			if bytes.Equal(magic[:n], []byte("\x7fELF")) {
+				elfs = append(elfs, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !buildinfo {
+		add(SeverityError, "package has no .BUILDINFO, it was not built with makepkg")
+	}
+	if len(info.Licenses) == 0 {
+		add(SeverityWarning, "package has no license")
+	}
+
+	filename := filepath.Base(pkgpath)
+	if !strings.HasSuffix(filename, "-"+info.Arch+".pkg.tar.zst") {
+		add(SeverityError, "package arch %s does not match file name %s", info.Arch, filename)
+	}
+	if info.Arch == "any" && len(elfs) > 0 {
+		add(SeverityError, "architecture independent package contains ELF binary: /%s", elfs[0])
+	}
+	if len(elfs) > 0 && len(info.Depends) == 0 {
+		add(SeverityWarning, "package contains ELF binaries, but has no depends")
+	}
+
+	packager, err := makepkgPackager()
+	if err == nil && packager != `` && info.Packager != packager {
+		add(SeverityWarning, "packager %s differs from makepkg.conf PACKAGER %s", info.Packager, packager)
+	}
+	return issues, nil
+}
+
+// Write lint report for package file, returns amount of errors found.
+func reportLint(out io.Writer, filename string, issues []LintIssue) int {
+	var errs int
+	for _, issue := range issues {
+		c := color.New(color.Bold, color.FgYellow)
+		if issue.Severity == SeverityError {
+			c = color.New(color.Bold, color.FgHiRed)
+			errs++
+		}
+		fmt.Fprintf(out, "%s %s: %s\n", c.Sprint(string(issue.Severity)+":"), filename, issue.Message)
+	}
+	return errs
+}
+
+// Lint package file and report issues, error is returned if package has
+// issues with error severity.
+func lintPackage(out io.Writer, pkgpath string) error {
+	issues, err := LintPackage(pkgpath)
+	if err != nil {
+		return err
+	}
+	errs := reportLint(out, filepath.Base(pkgpath), issues)
+	if errs > 0 {
+		return fmt.Errorf("%s has %d lint error(s)", filepath.Base(pkgpath), errs)
+	}
+	return nil
+}
+
+// Lint all packages in directory.
+func lintDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".pkg.tar.zst") {
+			err = lintPackage(os.Stdout, filepath.Join(dir, e.Name()))
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
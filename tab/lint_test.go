@@ -0,0 +1,80 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"ion.lc/core/tab/internal/testarchive"
+)
+
+// Use makepkg.conf with provided content instead of system one.
+func setMakepkgConf(t *testing.T, content string) {
+	conf := filepath.Join(t.TempDir(), "makepkg.conf")
+	assert.NoError(t, os.WriteFile(conf, []byte(content), 0o644))
+	prev := makepkgConf
+	makepkgConf = conf
+	t.Cleanup(func() { makepkgConf = prev })
+}
+
+func TestLintPackage(t *testing.T) {
+	dir := t.TempDir()
+	setMakepkgConf(t, "#PACKAGER=\"Nobody <nobody@example.com>\"\nPACKAGER=\"John Doe <john@example.com>\"\n")
+
+	good := filepath.Join(dir, "good-1-1-x86_64.pkg.tar.zst")
+	testarchive.WriteFile(t, good, testarchive.Zstd,
+		testarchive.Entry{Name: ".PKGINFO", Mode: 0o644, Data: "pkgname = good\npkgver = 1-1\narch = x86_64\nlicense = MIT\ndepend = glibc\n"},
+		testarchive.Entry{Name: ".BUILDINFO", Mode: 0o644, Data: "format = 2\n"},
+		testarchive.Entry{Name: "usr/bin/good", Mode: 0o755, Data: "\x7fELF binary"},
+	)
+	issues, err := LintPackage(good)
+	assert.NoError(t, err)
+	for _, issue := range issues {
+		assert.NotEqual(t, SeverityError, issue.Severity, issue.Message)
+	}
+
+	bad := filepath.Join(dir, "bad-1-1-x86_64.pkg.tar.zst")
+	testarchive.WriteFile(t, bad, testarchive.Zstd,
+		testarchive.Entry{Name: ".PKGINFO", Mode: 0o644, Data: "pkgname = bad\npkgver = 1-1\narch = any\n"},
+		testarchive.Entry{Name: "usr/local/bin/bad", Mode: 0o777, Data: "\x7fELF binary"},
+	)
+	issues, err = LintPackage(bad)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []LintIssue{
+		{SeverityError, "file is installed under /usr/local/: /usr/local/bin/bad"},
+		{SeverityError, "file is world-writable: /usr/local/bin/bad"},
+		{SeverityError, "package has no .BUILDINFO, it was not built with makepkg"},
+		{SeverityWarning, "package has no license"},
+		{SeverityError, "package arch any does not match file name bad-1-1-x86_64.pkg.tar.zst"},
+		{SeverityError, "architecture independent package contains ELF binary: /usr/local/bin/bad"},
+		{SeverityWarning, "package contains ELF binaries, but has no depends"},
+		{SeverityWarning, "packager  differs from makepkg.conf PACKAGER John Doe <john@example.com>"},
+	}, issues)
+}
+
+func TestLintMetadataOnce(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad-1-1-x86_64.pkg.tar.zst")
+	testarchive.WriteFile(t, bad, testarchive.Zstd,
+		testarchive.Entry{Name: ".PKGINFO", Mode: 0o644, Data: "pkgname = bad\npkgver = 1-1\narch = x86_64\n"},
+		testarchive.Entry{Name: "usr/local/bin/bad", Mode: 0o755, Data: "bad"},
+	)
+
+	var single, multiple bytes.Buffer
+	errs := lintMetadata([]PackageMetadata{{Path: bad}}, &single)
+	assert.Equal(t, 1, len(errs))
+
+	// Same package pushed to two targets is linted and reported once.
+	errs = lintMetadata([]PackageMetadata{{Path: bad}, {Path: bad}}, &multiple)
+	assert.Equal(t, 1, len(errs))
+	assert.Error(t, errs[bad])
+	assert.Equal(t, single.String(), multiple.String())
+}
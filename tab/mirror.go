@@ -0,0 +1,266 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mitchellh/ioprogress"
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
+)
+
+type MirrorParameters struct {
+	// Mirror only provided amount of newest versions, all versions if 0.
+	Latest int `short:"n" long:"latest"`
+	// Distribution in registries, that packages are mirrored for.
+	Distro string `short:"s" long:"distro" default:"archlinux"`
+	// Architecture used to download architecture independent packages.
+	Arch string `short:"a" long:"arch" default:"x86_64"`
+	// Use insecure connection for registry API calls.
+	Insecure bool `short:"i" long:"insecure"`
+}
+
+var MirrorHelp = `Mirror packages from one registry to another
+
+options:
+	-n, --latest <n> Mirror only N newest versions of each package (default all)
+	-s, --distro     Distribution in registries (default archlinux)
+	-a, --arch       Architecture to download 'any' packages from (default x86_64)
+	-i, --insecure   Use HTTP protocol for API calls
+
+usage: tab --mirror [options] <src-registry/owner> <dst-registry/owner> [package(s)]`
+
+// Mirrored package version.
+type mirrored struct {
+	Name    string
+	Version string
+	Files   []string
+	Exists  bool
+	Err     error
+}
+
+// Copy packages with signatures from source registry to destination. Package
+// files that already exist in destination are skipped, so mirror can be
+// repeated to copy only new versions and files missing after failed runs.
+func Mirror(args []string, prms ...MirrorParameters) error {
+	p := getParameters(prms)
+
+	if len(args) < 2 {
+		return errors.New("specify source and destination registries")
+	}
+	src, err := registry.Open(args[0], p.Insecure)
+	if err != nil {
+		return err
+	}
+	dst, err := registry.Open(args[1], p.Insecure)
+	if err != nil {
+		return err
+	}
+
+	msgs.Amsg(os.Stdout, "Listing packages in "+src.String())
+	names := args[2:]
+	if len(names) == 0 {
+		names, err = packageNames(src)
+		if err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.MkdirTemp(``, "tab-mirror-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	msgs.Amsg(os.Stdout, "Mirroring packages to "+dst.String())
+	var results []mirrored
+	for i, name := range names {
+		msgs.Smsg(os.Stdout, "Mirroring "+name, i+1, len(names))
+		results = append(results, mirrorPackage(p, src, dst, name, tmp)...)
+	}
+
+	msgs.Amsg(os.Stdout, "Mirror diff")
+	var errs []error
+	var copied, existing int
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			errs = append(errs, fmt.Errorf("%s@%s: %w", r.Name, r.Version, r.Err))
+			color.New(color.FgRed).Printf("! %s %s: %s\n", r.Name, r.Version, r.Err)
+		case r.Exists:
+			existing++
+		default:
+			copied++
+			for _, file := range r.Files {
+				color.New(color.FgGreen).Printf("+ %s %s (%s)\n", r.Name, r.Version, file)
+			}
+		}
+	}
+	fmt.Printf("%d copied, %d up to date, %d failed\n", copied, existing, len(errs))
+	return joinFailures(errs, len(results)-existing)
+}
+
+// List names of all packages published in registry.
+func packageNames(reg registry.Registry) ([]string, error) {
+	pkgs, err := reg.List(``)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pkg := range pkgs {
+		if !slices.Contains(names, pkg.Name) {
+			names = append(names, pkg.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Mirror versions of single package, that are missing in destination.
+func mirrorPackage(p *MirrorParameters, src, dst registry.Registry, name, tmp string) []mirrored {
+	versions, err := src.List(name)
+	if err != nil {
+		return []mirrored{{Name: name, Err: err}}
+	}
+	if len(versions) == 0 {
+		return []mirrored{{Name: name, Err: registry.ErrPackageNotFound}}
+	}
+	// Newest versions go first in pacman version order, upload time can
+	// differ from it when old versions are pushed again.
+	slices.SortFunc(versions, func(a, b registry.Package) int {
+		return pacman.Vercmp(b.Version, a.Version)
+	})
+	if p.Latest > 0 && len(versions) > p.Latest {
+		versions = versions[:p.Latest]
+	}
+
+	published, err := dst.List(name)
+	if err != nil {
+		return []mirrored{{Name: name, Err: err}}
+	}
+
+	// Files are compared for published versions, so versions, that were
+	// copied partially, are completed on next run.
+	var results []mirrored
+	for _, v := range versions {
+		r := mirrored{Name: name, Version: v.Version}
+		var existing []string
+		if slices.ContainsFunc(published, func(pv registry.Package) bool { return pv.Version == v.Version }) {
+			files, err := dst.Files(name, v.Version)
+			if err != nil && !errors.Is(err, registry.ErrPackageNotFound) {
+				r.Err = err
+				results = append(results, r)
+				continue
+			}
+			for _, f := range files {
+				existing = append(existing, f.Name)
+			}
+		}
+		r.Files, r.Err = mirrorVersion(p, src, dst, name, v.Version, tmp, existing)
+		r.Exists = r.Err == nil && len(r.Files) == 0 && len(existing) > 0
+		results = append(results, r)
+	}
+	return results
+}
+
+// Download package files of version with signatures, verify them and push
+// to destination registry together with build provenance. Files, that
+// already exist in destination, are skipped. Returns list of copied files.
+func mirrorVersion(p *MirrorParameters, src, dst registry.Registry, name, version, tmp string, existing []string) ([]string, error) {
+	files, err := src.Files(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied []string
+	for _, f := range files {
+		file := f.Name
+		if !strings.HasSuffix(file, ".pkg.tar.zst") || slices.Contains(existing, file) {
+			continue
+		}
+		parsed, err := pacman.ParsePackageFile(file)
+		if err != nil {
+			return copied, err
+		}
+		arch := parsed.Arch
+		if arch == "any" {
+			arch = p.Arch
+		}
+
+		pkgpath := filepath.Join(tmp, file)
+		err = download(src, p.Distro, arch, file, pkgpath)
+		if err != nil {
+			return copied, err
+		}
+		err = download(src, p.Distro, arch, file+".sig", pkgpath+".sig")
+		if err != nil {
+			return copied, err
+		}
+		err = VerifySignature(pkgpath)
+		if err != nil {
+			return copied, err
+		}
+		sig, err := os.ReadFile(pkgpath + ".sig")
+		if err != nil {
+			return copied, err
+		}
+
+		_, err = dst.Push(&registry.PushParameters{
+			Path:      pkgpath,
+			Signature: sig,
+			Distro:    p.Distro,
+			Progress:  ioprogress.DrawTerminal(os.Stdout),
+		})
+		os.Remove(pkgpath)
+		os.Remove(pkgpath + ".sig")
+		if err != nil {
+			return copied, err
+		}
+		copied = append(copied, file)
+
+		prov, err := src.Attachment(name, version, file+provenanceSuffix)
+		if errors.Is(err, registry.ErrPackageNotFound) {
+			continue
+		}
+		if err != nil {
+			return copied, err
+		}
+		_, err = dst.Attach(name, version, file+provenanceSuffix, prov)
+		if err != nil {
+			return copied, err
+		}
+	}
+	return copied, nil
+}
+
+// Download file from registry to provided path.
+func download(reg registry.Registry, distro, arch, file, dst string) error {
+	r, err := reg.Download(distro, arch, file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,163 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"ion.lc/core/tab/alpm"
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+)
+
+// Remove packages installed as dependencies, that are not required by other
+// packages. Removal is repeated, since removed orphans can leave their own
+// dependencies unrequired. Packages deselected by user are kept.
+func removeOrphans(p *RemoveParameters, out io.Writer) error {
+	var kept []string
+	for round := 1; ; round++ {
+		msgs.Amsg(out, "Searching for orphan packages")
+		orphans, err := pacman.Orphans()
+		if err != nil {
+			return err
+		}
+		orphans = slices.DeleteFunc(orphans, func(pkg string) bool {
+			return slices.Contains(kept, pkg)
+		})
+		if len(orphans) == 0 {
+			if round == 1 {
+				msgs.Amsg(out, "No orphan packages found")
+			}
+			return nil
+		}
+
+		err = printOrphans(out, orphans)
+		if err != nil {
+			return err
+		}
+
+		selected := orphans
+		if !p.Quick {
+			selected, err = selectOrphans(out, orphans)
+			if err != nil {
+				return err
+			}
+		}
+		for _, pkg := range orphans {
+			if !slices.Contains(selected, pkg) {
+				kept = append(kept, pkg)
+			}
+		}
+		if len(selected) == 0 {
+			return nil
+		}
+
+		msgs.Amsg(out, "Removing orphan packages")
+		err = pacman.RemoveList(selected, pacman.RemoveParameters{
+			Sudo:        true,
+			NoConfirm:   true,
+			WithConfigs: !p.Nocfgs,
+			Stdout:      out,
+			Stderr:      os.Stderr,
+			Stdin:       os.Stdin,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Print numbered list of orphans with installed size and repository, that
+// package was installed from. Both are read from local and sync databases.
+func printOrphans(out io.Writer, orphans []string) error {
+	conf, err := os.ReadFile("/etc/pacman.conf")
+	if err != nil {
+		return err
+	}
+	dbpath := confDBPath(string(conf))
+	local, err := alpm.ReadLocal(dbpath, false)
+	if err != nil {
+		return err
+	}
+	sizes := map[string]int64{}
+	for _, pkg := range local {
+		sizes[pkg.Name] = pkg.Size
+	}
+	repos := syncRepositories(dbpath, string(conf))
+
+	var rows [][]string
+	for i, pkg := range orphans {
+		size := "?"
+		if n, ok := sizes[pkg]; ok {
+			size = formatSize(n)
+		}
+		repo, ok := repos[pkg]
+		if !ok {
+			repo = "local"
+		}
+		rows = append(rows, []string{strconv.Itoa(i + 1), pkg, size, repo})
+	}
+	msgs.Table(out, []string{"#", "package", "size", "registry"}, rows)
+	return nil
+}
+
+// Ask user for orphans, that should be kept, returns packages that should
+// be removed.
+func selectOrphans(out io.Writer, orphans []string) ([]string, error) {
+	for {
+		input, err := msgs.Inp(
+			"Packages to keep (e.g. 1 3 5-7), empty to remove all listed: ",
+			out, os.Stdin, false,
+		)
+		if err != nil {
+			return nil, err
+		}
+		keep, err := parseSelection(input, len(orphans))
+		if err != nil {
+			fmt.Fprintln(out, msgs.Err+err.Error())
+			continue
+		}
+
+		var selected []string
+		for i, pkg := range orphans {
+			if !slices.Contains(keep, i+1) {
+				selected = append(selected, pkg)
+			}
+		}
+		return selected, nil
+	}
+}
+
+// Parse selection of list items in "1 3 5-7" format, items are numbered
+// from 1 to total.
+func parseSelection(input string, total int) ([]int, error) {
+	var selection []int
+	for _, field := range strings.Fields(strings.ReplaceAll(input, ",", " ")) {
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("not valid selection: %s", field)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(to)
+			if err != nil {
+				return nil, fmt.Errorf("not valid selection: %s", field)
+			}
+		}
+		if start < 1 || end > total || start > end {
+			return nil, fmt.Errorf("selection out of range: %s", field)
+		}
+		for i := start; i <= end; i++ {
+			selection = append(selection, i)
+		}
+	}
+	return selection, nil
+}
@@ -0,0 +1,176 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"ion.lc/core/tab/alpm"
+	"ion.lc/core/tab/pacman"
+)
+
+// List outdated packages without root priveleges and without changes to
+// system databases. Sync databases of all repositories from pacman.conf,
+// including registry databases, are downloaded to temporary directory and
+// compared with versions from local database. Repositories, that can not be
+// checked, are reported with ErrPartialFailure together with packages found
+// in the rest of repositories.
+func CheckOutdated() ([]pacman.OutdatedPackage, error) {
+	conf, err := os.ReadFile("/etc/pacman.conf")
+	if err != nil {
+		return nil, err
+	}
+	return outdatedPackages(string(conf), confDBPath(string(conf)))
+}
+
+// Compare packages in local database of system dbpath with sync databases
+// of repositories from pacman.conf.
+func outdatedPackages(conf, sysdbpath string) ([]pacman.OutdatedPackage, error) {
+	local, err := alpm.ReadLocal(sysdbpath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp(``, "tab-outdated-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// Package versions from first repository, that contains package, are
+	// used, the same way pacman does.
+	latest := map[string]alpm.Package{}
+	repos := map[string]string{}
+	var errs []error
+	for _, repo := range confRepositories(conf) {
+		pkgs, err := syncDatabase(conf, repo, filepath.Join(tmp, repo+".db"))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, pkg := range pkgs {
+			if _, ok := latest[pkg.Name]; !ok {
+				latest[pkg.Name] = pkg
+				repos[pkg.Name] = repo
+			}
+		}
+	}
+
+	var outdated []pacman.OutdatedPackage
+	for _, pkg := range local {
+		available, ok := latest[pkg.Name]
+		if !ok || pacman.Vercmp(available.Version, pkg.Version) <= 0 {
+			continue
+		}
+		outdated = append(outdated, pacman.OutdatedPackage{
+			Name:           pkg.Name,
+			CurrentVersion: pkg.Version,
+			NewVersion:     available.Version,
+			Repository:     repos[pkg.Name],
+		})
+	}
+	return outdated, joinFailures(errs, len(confRepositories(conf)))
+}
+
+// Download sync database of repository, verify its signature according to
+// SigLevel from pacman.conf and read packages from it.
+func syncDatabase(conf, repo, dbfile string) ([]alpm.Package, error) {
+	err := downloadDatabase(conf, repo, dbfile)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyDatabase(conf, repo, dbfile)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := alpm.ReadSyncFile(dbfile)
+	if err != nil {
+		return nil, errors.New("unable to read " + repo + " database: " + err.Error())
+	}
+	return pkgs, nil
+}
+
+// Download sync database of repository from first available server. Database
+// signature is downloaded next to it, if server provides one.
+func downloadDatabase(conf, repo, dst string) error {
+	servers := confServers(conf, repo)
+	if len(servers) == 0 {
+		return errors.New("no servers found for repository " + repo)
+	}
+	var errs []error
+	for _, server := range servers {
+		link := strings.NewReplacer("$repo", repo, "$arch", machineArch()).Replace(server)
+		err := downloadFile(link+"/"+repo+".db", dst)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		err = downloadFile(link+"/"+repo+".db.sig", dst+".sig")
+		if err != nil && !errors.Is(err, errFileNotFound) {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	return errors.Join(append([]error{errors.New("unable to download database " + repo)}, errs...)...)
+}
+
+// Verify signature of downloaded database with pacman keyring. Signature is
+// required or checked only if present, depending on database SigLevel.
+func verifyDatabase(conf, repo, dbfile string) error {
+	level := databaseSigLevel(conf, repo)
+	if level == "Never" {
+		return nil
+	}
+	_, err := os.Stat(dbfile + ".sig")
+	if errors.Is(err, os.ErrNotExist) {
+		if level == "Required" {
+			return errors.New("signature of " + repo + " database is required, but missing")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	gpgdir := confOption(conf, "options", "GPGDir")
+	if gpgdir == `` {
+		gpgdir = "/etc/pacman.d/gnupg"
+	}
+	var b bytes.Buffer
+	cmd := exec.Command("gpgv", "--keyring", filepath.Join(gpgdir, "pubring.gpg"), dbfile+".sig", dbfile)
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err = cmd.Run()
+	if err != nil {
+		return errors.New("unable to verify signature of " + repo + " database: " + strings.TrimSpace(b.String()))
+	}
+	return nil
+}
+
+// Get signature level for database of repository: Never, Optional or
+// Required. Repository SigLevel overrides global one from options section,
+// which overrides pacman default of optional database signatures.
+func databaseSigLevel(conf, repo string) string {
+	level := "Optional"
+	for _, section := range []string{"options", repo} {
+		for _, token := range strings.Fields(confOption(conf, section, "SigLevel")) {
+			if strings.HasPrefix(token, "Package") {
+				continue
+			}
+			token = strings.TrimPrefix(token, "Database")
+			switch token {
+			case "Never", "Optional", "Required":
+				level = token
+			}
+		}
+	}
+	return level
+}
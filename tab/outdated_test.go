@@ -0,0 +1,105 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"ion.lc/core/tab/creds"
+	"ion.lc/core/tab/internal/testarchive"
+	"ion.lc/core/tab/pacman"
+)
+
+// Write sync database with provided package versions.
+func writeSyncDatabase(t *testing.T, file string, versions map[string]string) {
+	var entries []testarchive.Entry
+	for name, version := range versions {
+		entries = append(entries, testarchive.Entry{
+			Name: name + "-" + version + "/desc",
+			Mode: 0o644,
+			Data: testarchive.Desc("NAME", name, "VERSION", version),
+		})
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	testarchive.WriteFile(t, file, testarchive.Gzip, entries...)
+}
+
+func TestOutdatedPackages(t *testing.T) {
+	sysdbpath := t.TempDir()
+	for name, version := range map[string]string{"nano": "7.2-1", "vim": "9.0-1", "tool": "1.0-1"} {
+		entry := filepath.Join(sysdbpath, "local", name+"-"+version)
+		assert.NoError(t, os.MkdirAll(entry, 0o755))
+		desc := testarchive.Desc("NAME", name, "VERSION", version)
+		assert.NoError(t, os.WriteFile(filepath.Join(entry, "desc"), []byte(desc), 0o644))
+	}
+
+	repos := t.TempDir()
+	writeSyncDatabase(t, filepath.Join(repos, "core", "core.db"), map[string]string{"nano": "7.2-2", "vim": "9.0-1"})
+	writeSyncDatabase(t, filepath.Join(repos, "team", "team.db"), map[string]string{"nano": "8.0-1", "tool": "1:0.1-1"})
+
+	conf := "[options]\nArchitecture = auto\nSigLevel = Required DatabaseOptional\n\n" +
+		"[core]\nServer = file://" + repos + "/$repo\n\n" +
+		"[team]\nServer = file://" + repos + "/team\n"
+
+	outdated, err := outdatedPackages(conf, sysdbpath)
+	assert.NoError(t, err)
+	assert.Equal(t, []pacman.OutdatedPackage{
+		{Name: "nano", CurrentVersion: "7.2-1", NewVersion: "7.2-2", Repository: "core"},
+		{Name: "tool", CurrentVersion: "1.0-1", NewVersion: "1:0.1-1", Repository: "team"},
+	}, outdated)
+
+	// Unreachable repository and repository without required signature are
+	// reported, packages from the rest of repositories are still checked.
+	writeSyncDatabase(t, filepath.Join(repos, "unsigned", "unsigned.db"), map[string]string{"vim": "9.1-1"})
+	conf += "\n[gone]\nServer = file://" + repos + "/gone\n" +
+		"\n[unsigned]\nSigLevel = DatabaseRequired\nServer = file://" + repos + "/$repo\n"
+	outdated, err = outdatedPackages(conf, sysdbpath)
+	assert.True(t, errors.Is(err, ErrPartialFailure))
+	assert.Contains(t, err.Error(), "unable to download database gone")
+	assert.Contains(t, err.Error(), "signature of unsigned database is required")
+	assert.Equal(t, 2, len(outdated))
+}
+
+func TestDatabaseSigLevel(t *testing.T) {
+	conf := "[options]\nSigLevel = Required DatabaseOptional\n\n" +
+		"[core]\n\n[signed]\nSigLevel = DatabaseRequired PackageNever\n\n[never]\nSigLevel = Never\n"
+
+	assert.Equal(t, "Optional", databaseSigLevel(conf, "core"))
+	assert.Equal(t, "Required", databaseSigLevel(conf, "signed"))
+	assert.Equal(t, "Never", databaseSigLevel(conf, "never"))
+	assert.Equal(t, "Optional", databaseSigLevel("[core]\n", "core"))
+}
+
+func TestDownloadFileCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		login, pass, ok := r.BasicAuth()
+		if !ok || login != "john" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("database"))
+	}))
+	defer srv.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	dst := filepath.Join(t.TempDir(), "private.db")
+	assert.Error(t, downloadFile(srv.URL+"/private.db", dst))
+
+	u, err := url.Parse(srv.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, creds.Put(u.Scheme, u.Host, "john", "secret"))
+	assert.NoError(t, downloadFile(srv.URL+"/private.db", dst))
+	b, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, "database", string(b))
+}
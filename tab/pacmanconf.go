@@ -0,0 +1,180 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"ion.lc/core/tab/alpm"
+	"ion.lc/core/tab/creds"
+)
+
+// Get repository names from pacman.conf in the order they are defined.
+func confRepositories(conf string) []string {
+	var repos []string
+	for _, line := range strings.Split(conf, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			repo := strings.Trim(line, "[]")
+			if repo != "options" {
+				repos = append(repos, repo)
+			}
+		}
+	}
+	return repos
+}
+
+// Get servers of repository from pacman.conf, included mirror lists are read
+// as well.
+func confServers(conf, repo string) []string {
+	var servers []string
+	for _, value := range confValues(conf, repo, "Server", "Include") {
+		key, value, _ := strings.Cut(value, "=")
+		switch key {
+		case "Server":
+			servers = append(servers, strings.TrimSuffix(value, "/"))
+		case "Include":
+			mirrorlist, err := os.ReadFile(value)
+			if err == nil {
+				servers = append(servers, confServers("["+repo+"]\n"+string(mirrorlist), repo)...)
+			}
+		}
+	}
+	return servers
+}
+
+// Get option from section of pacman.conf, last value is used if option is
+// repeated. Empty string is returned for missing options.
+func confOption(conf, section, key string) string {
+	values := confValues(conf, section, key)
+	if len(values) == 0 {
+		return ``
+	}
+	_, value, _ := strings.Cut(values[len(values)-1], "=")
+	return value
+}
+
+// Get database path from pacman.conf, default path is used if it is not set.
+func confDBPath(conf string) string {
+	dbpath := confOption(conf, "options", "DBPath")
+	if dbpath == `` {
+		return alpm.DefaultDBPath
+	}
+	return strings.TrimSuffix(dbpath, "/")
+}
+
+// Get database path from system pacman.conf, default path is used if
+// pacman.conf can not be read.
+func systemDBPath() string {
+	conf, err := os.ReadFile("/etc/pacman.conf")
+	if err != nil {
+		return alpm.DefaultDBPath
+	}
+	return confDBPath(string(conf))
+}
+
+// Get values of keys in section of pacman.conf in key=value form with
+// trimmed spaces, in the order they are defined.
+func confValues(conf, section string, keys ...string) []string {
+	var values []string
+	var current string
+	for _, line := range strings.Split(conf, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.Trim(line, "[]")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || current != section || !slices.Contains(keys, key) {
+			continue
+		}
+		values = append(values, key+"="+strings.TrimSpace(value))
+	}
+	return values
+}
+
+// Get pacman architecture of current machine.
+func machineArch() string {
+	switch runtime.GOARCH {
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i686"
+	}
+	return syncArch
+}
+
+// Client for package and database downloads. Connection and response
+// timeouts prevent dead mirrors from blocking, whole download is limited
+// with large timeout, since packages can be big.
+var downloadClient = &http.Client{
+	Timeout: 30 * time.Minute,
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   30 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+}
+
+// Returned by downloadFile, when file does not exist on server.
+var errFileNotFound = errors.New("file not found")
+
+// Download file by link, file:// links are copied from local file system.
+// Stored credentials of server host are sent, so databases and packages of
+// private registries can be downloaded.
+func downloadFile(link, dst string) error {
+	var r io.ReadCloser
+	if path, ok := strings.CutPrefix(link, "file://"); ok {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to download %s: %w", link, errFileNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		r = f
+	} else {
+		req, err := http.NewRequest(http.MethodGet, link, nil)
+		if err != nil {
+			return err
+		}
+		login, pass, err := creds.Get(req.URL.Scheme, req.URL.Host)
+		if err == nil {
+			req.SetBasicAuth(login, pass)
+		}
+		resp, err := downloadClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return fmt.Errorf("unable to download %s: %w", link, errFileNotFound)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unable to download %s: %s", link, resp.Status)
+		}
+		r = resp.Body
+	}
+	defer r.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return errors.Join(err, f.Close())
+}
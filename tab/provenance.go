@@ -0,0 +1,191 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
+)
+
+// Suffix of provenance document file, that is stored next to package file.
+const provenanceSuffix = ".provenance.json"
+
+// Build provenance of package, describes where and how package was built.
+type Provenance struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Arch    string `json:"arch"`
+	// SHA256 checksum of package file.
+	SHA256 string `json:"sha256"`
+	// Git repository package was built from and commit of build.
+	Source string `json:"source"`
+	Commit string `json:"commit"`
+	// Host name of machine, where package was built.
+	Builder   string    `json:"builder"`
+	BuildTime time.Time `json:"build_time"`
+	// Version of makepkg used for build and flags it was called with.
+	Toolchain    string   `json:"toolchain"`
+	MakepkgFlags []string `json:"makepkg_flags"`
+	// Fingerprint of GnuPG key package was signed with.
+	Signer string `json:"signer"`
+}
+
+// Write provenance documents for all packages built in directory. Source is
+// repository that was cloned for build, remote of directory repository is
+// used if empty. Signer is fingerprint of key packages were signed with.
+func WriteProvenance(dir, source, signer string, flags []string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if source == `` {
+		source = gitOutput(dir, "remote", "get-url", "origin")
+	}
+	commit := gitOutput(dir, "rev-parse", "HEAD")
+	builder, _ := os.Hostname()
+	toolchain := commandOutput("makepkg", "--version")
+
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".pkg.tar.zst") {
+			continue
+		}
+		pkgpath := filepath.Join(dir, e.Name())
+		info, err := pacman.ReadPkginfo(pkgpath)
+		if err != nil {
+			return err
+		}
+		sum, err := fileSHA256(pkgpath)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(Provenance{
+			Package:      info.Name,
+			Version:      info.Version,
+			Arch:         info.Arch,
+			SHA256:       sum,
+			Source:       source,
+			Commit:       commit,
+			Builder:      builder,
+			BuildTime:    time.Unix(info.BuildDate, 0).UTC(),
+			Toolchain:    toolchain,
+			MakepkgFlags: flags,
+			Signer:       signer,
+		}, ``, "  ")
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(pkgpath+provenanceSuffix, append(b, '\n'), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Push provenance document stored next to package file, if it exists.
+func pushProvenance(md PackageMetadata) (int, error) {
+	b, err := os.ReadFile(md.Path + provenanceSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return md.Registry.Attach(md.Name, md.Version, md.FileName+provenanceSuffix, b)
+}
+
+// Fetch provenance documents of package version from registry, latest
+// version is used if version is empty.
+func fetchProvenance(reg registry.Registry, name, version string) ([][]byte, error) {
+	if version == `` {
+		pkgs, err := reg.List(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			return nil, errors.New("package not found in registry: " + name)
+		}
+		// Upload time is not used, since old versions can be pushed again
+		// or mirrored after newer ones.
+		latest := pkgs[0]
+		for _, pkg := range pkgs[1:] {
+			if pacman.Vercmp(pkg.Version, latest.Version) > 0 {
+				latest = pkg
+			}
+		}
+		version = latest.Version
+	}
+
+	files, err := reg.Files(name, version)
+	if err != nil {
+		return nil, err
+	}
+	var docs [][]byte
+	for _, f := range files {
+		file := f.Name
+		if !strings.HasSuffix(file, ".pkg.tar.zst") {
+			continue
+		}
+		b, err := reg.Attachment(name, version, file+provenanceSuffix)
+		if errors.Is(err, registry.ErrPackageNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, b)
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("no provenance found for " + name + "@" + version)
+	}
+	return docs, nil
+}
+
+// Get hex encoded SHA256 checksum of file.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ``, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return ``, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get trimmed output of git command in directory, empty if command failed.
+func gitOutput(dir string, args ...string) string {
+	return commandOutput("git", append([]string{"-C", dir}, args...)...)
+}
+
+// Get first line of command output, empty if command failed.
+func commandOutput(name string, args ...string) string {
+	var b bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &b
+	err := cmd.Run()
+	if err != nil {
+		return ``
+	}
+	line, _, _ := strings.Cut(b.String(), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,71 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"ion.lc/core/tab/internal/testarchive"
+	"ion.lc/core/tab/registry"
+)
+
+func TestWriteProvenance(t *testing.T) {
+	dir := t.TempDir()
+	pkgpath := filepath.Join(dir, "good-1-1-x86_64.pkg.tar.zst")
+	testarchive.WriteFile(t, pkgpath, testarchive.Zstd,
+		testarchive.Entry{Name: ".PKGINFO", Mode: 0o644, Data: "pkgname = good\npkgver = 1-1\narch = x86_64\nbuilddate = 1705140000\n"},
+		testarchive.Entry{Name: "usr/bin/good", Mode: 0o755, Data: "good"},
+	)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "PKGBUILD"), []byte("pkgname=good\n"), 0o644))
+
+	flags := []string{"--sign", "--clean"}
+	err := WriteProvenance(dir, "https://example.com/owner/good", "ABCDEF0123456789", flags)
+	assert.NoError(t, err)
+
+	b, err := os.ReadFile(pkgpath + provenanceSuffix)
+	assert.NoError(t, err)
+	var prov Provenance
+	assert.NoError(t, json.Unmarshal(b, &prov))
+
+	sum, err := fileSHA256(pkgpath)
+	assert.NoError(t, err)
+	assert.Equal(t, "good", prov.Package)
+	assert.Equal(t, "1-1", prov.Version)
+	assert.Equal(t, "x86_64", prov.Arch)
+	assert.Equal(t, sum, prov.SHA256)
+	assert.Equal(t, "https://example.com/owner/good", prov.Source)
+	assert.Equal(t, "ABCDEF0123456789", prov.Signer)
+	assert.Equal(t, flags, prov.MakepkgFlags)
+	assert.Equal(t, time.Unix(1705140000, 0).UTC(), prov.BuildTime)
+
+	_, err = os.Stat(filepath.Join(dir, "PKGBUILD"+provenanceSuffix))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFetchLatestProvenance(t *testing.T) {
+	reg := &registry.Dir{Root: t.TempDir()}
+	archdir := filepath.Join(reg.Root, "archlinux", "x86_64")
+	assert.NoError(t, os.MkdirAll(archdir, 0o755))
+	uploaded := time.Now()
+	// Old version is uploaded again after newer one, for example by mirror.
+	for i, version := range []string{"1.10-1", "1.9-1"} {
+		file := "good-" + version + "-x86_64.pkg.tar.zst"
+		path := filepath.Join(archdir, file)
+		assert.NoError(t, os.WriteFile(path, []byte(version), 0o644))
+		assert.NoError(t, os.Chtimes(path, uploaded, uploaded.Add(time.Duration(i)*time.Hour)))
+		_, err := reg.Attach("good", version, file+provenanceSuffix, []byte(version))
+		assert.NoError(t, err)
+	}
+
+	docs, err := fetchProvenance(reg, "good", ``)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("1.10-1")}, docs)
+}
@@ -0,0 +1,153 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
+)
+
+// Remove old package versions from registry. Newest versions in pacman
+// version order and protected versions are kept, other versions are removed
+// if they are older than provided age.
+func prune(p *RemoveParameters, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("specify registry/owner to prune")
+	}
+	keep, err := p.keep(1)
+	if err != nil {
+		return err
+	}
+	age, err := parseAge(p.OlderThan)
+	if err != nil {
+		return err
+	}
+
+	reg, err := registry.Open(args[0], p.Insecure)
+	if err != nil {
+		return err
+	}
+
+	msgs.Amsg(out, "Listing packages in "+reg.String())
+	names := args[1:]
+	if len(names) == 0 {
+		names, err = packageNames(reg)
+		if err != nil {
+			return err
+		}
+	}
+
+	var plan []remoteRemoval
+	for i, name := range names {
+		msgs.Smsg(out, "Listing versions of "+name, i+1, len(names))
+		pkgs, err := reg.List(name)
+		if err != nil {
+			return err
+		}
+		pruned, err := prunedVersions(p, pkgs, keep, age)
+		if err != nil {
+			return err
+		}
+		for _, version := range pruned {
+			plan = append(plan, remoteRemoval{Registry: reg, Name: name, Version: version})
+		}
+	}
+
+	if len(plan) == 0 {
+		msgs.Amsg(out, "Nothing to prune")
+		return nil
+	}
+
+	proceed, err := reviewRemoval(p, plan, out)
+	if err != nil || !proceed {
+		return err
+	}
+
+	msgs.Amsg(out, "Removing remote packages")
+	return removeRemote(p, plan, out)
+}
+
+// Select versions of package, that should be pruned by retention policy.
+func prunedVersions(p *RemoveParameters, pkgs []registry.Package, keep int, age time.Duration) ([]string, error) {
+	slices.SortFunc(pkgs, func(a, b registry.Package) int {
+		return pacman.Vercmp(b.Version, a.Version)
+	})
+	pkgs = slices.CompactFunc(pkgs, func(a, b registry.Package) bool {
+		return a.Version == b.Version
+	})
+	if len(pkgs) <= keep {
+		return nil, nil
+	}
+
+	var pruned []string
+	for _, pkg := range pkgs[keep:] {
+		protected, err := isProtected(p.Protect, pkg)
+		if err != nil {
+			return nil, err
+		}
+		if protected {
+			continue
+		}
+		if age > 0 && time.Since(pkg.Created) < age {
+			continue
+		}
+		pruned = append(pruned, pkg.Version)
+	}
+	return pruned, nil
+}
+
+// Check whether package version matches one of protected package@version
+// selectors.
+func isProtected(protect []string, pkg registry.Package) (bool, error) {
+	for _, pin := range protect {
+		name, selector, err := splitVer(pin)
+		if err != nil {
+			return false, err
+		}
+		if name != pkg.Name {
+			continue
+		}
+		ok, err := matchVersion(pkg.Version, selector)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// Parse age in duration format, which additionally supports days (d) and
+// weeks (w). Empty age is parsed to 0.
+func parseAge(s string) (time.Duration, error) {
+	if s == `` {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if !strings.HasSuffix(s, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("not valid age: %s", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("not valid age: %s", s)
+	}
+	return d, nil
+}
@@ -5,19 +5,19 @@
 package tab
 
 import (
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"os/exec"
 	"path"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/ioprogress"
-	"ion.lc/core/tab/creds"
 	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
 )
 
 // Parameters that will be used to execute push command.
@@ -30,17 +30,59 @@ type PushParameters struct {
 	Distro string `short:"s" long:"distro" default:"archlinux"`
 	// Export public GPG key armor.
 	Export bool `short:"e" long:"export"`
+	// Sign packages without signature before pushing.
+	Sign bool `short:"g" long:"sign"`
+	// GnuPG key used for signing, first ultimate identity by default.
+	Key string `short:"k" long:"key"`
+	// Registry targets to push packages to, can be provided multiple times.
+	To []string `short:"t" long:"to"`
+	// Replace package versions, that already exist in registry.
+	Replace bool `short:"r" long:"replace"`
+	// Treat package versions, that already exist in registry, as pushed.
+	SkipExisting bool `short:"x" long:"skip-existing"`
+	// Do not ask for any confirmation.
+	Quick bool `short:"q" long:"quick"`
+	// Write push results as JSON stream.
+	JSON bool `short:"j" long:"json"`
+	// Architecture databases where architecture independent packages should
+	// be present, can be provided multiple times.
+	Archs []string `short:"a" long:"arch" default:"x86_64"`
+	// Upload exported GPG key to registry account.
+	Upload string `short:"u" long:"upload"`
+	// Write exported GPG key to file instead of stdout.
+	Output string `short:"o" long:"output"`
+	// Do not lint packages before pushing.
+	NoLint bool `short:"n" long:"nolint"`
 }
 
-var PushHelp = `Push cached packages
+var PushHelp = `Push cached packages or package files
 
 options:
 	-d, --dir <dir> Use custom source dir with packages (default pacman cache)
+	                Ignored when package files or globs are provided
 	-i, --insecure  Push package over HTTP instead of HTTPS
 	-s, --distro    Assign custom distribution in registry (default archlinux)
 	-e, --export    Export public GPG key armor
+	-u, --upload <registry>
+	                Upload exported key to registry account GPG keys
+	-o, --output <file>
+	                Write exported key to file instead of stdout
+	-g, --sign      Sign packages without signature before pushing
+	-k, --key <key> Use specific GnuPG key for signing and export, can be
+	                fingerprint, key id or email (default ultimate uid)
+	-t, --to <reg>  Push to registry/owner target, can be used multiple times
+	-r, --replace   Replace package versions that already exist in registry
+	-x, --skip-existing
+	                Treat versions that already exist in registry as pushed
+	-q, --quick     Do not ask for any confirmation
+	-j, --json      Write push results as JSON stream to stdout
+	-a, --arch      Ensure 'any' packages are present in architecture database,
+	                can be used multiple times (default x86_64)
+	-n, --nolint    Do not check packages for packaging problems before pushing
 
-usage: tab {-P --push} [options] <registry/owner/package(s)>`
+usage: tab {-P --push} [options] <registry/owner/package(s)>
+       tab {-P --push} [options] <registry/owner> <package/file(s)/glob(s)>
+       tab {-P --push} [options] --to <registry/owner> <package/file(s)/glob(s)>`
 
 // Push your package to registry.
 func Push(args []string, prms ...PushParameters) error {
@@ -50,72 +92,435 @@ func Push(args []string, prms ...PushParameters) error {
 		return Export(p)
 	}
 
-	msgs.Amsg(os.Stdout, "Preparing pushed packages")
+	out := humanOutput(p.JSON)
 
-	cachedpkgs, err := listPkgFilenames(p.Directory)
+	msgs.Amsg(out, "Preparing pushed packages")
+
+	mds, err := collectMetadata(p, args, out)
 	if err != nil {
 		return err
 	}
-	msgs.Smsg(os.Stdout, "Scanning cached packages", 1, 2)
 
-	mds, err := prepareMetadata(p.Directory, cachedpkgs, args)
-	if err != nil {
-		return err
+	var lintErrs map[string]error
+	if !p.NoLint {
+		msgs.Amsg(out, "Linting packages")
+		lintErrs = lintMetadata(mds, out)
 	}
-	msgs.Smsg(os.Stdout, "Preparing package metadata", 2, 2)
 
-	msgs.Amsg(os.Stdout, "Pushing packages")
-	for i, md := range mds {
-		err = push(*p, md, i+1, len(mds))
+	if p.Sign {
+		err := signMissing(mds, p.Key, out)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+
+	msgs.Amsg(out, "Pushing packages")
+	var results []pushResult
+	for i, md := range mds {
+		r := pushPackage(p, md, lintErrs[md.Path], i+1, len(mds), out)
+		results = append(results, r)
+		if p.JSON {
+			err := writeResult(os.Stdout, r.Result)
+			if err != nil {
+				return err
+			}
+		}
+		if errors.Is(r.Err, registry.ErrVersionExists) && p.SkipExisting {
+			msgs.Smsg(out, "Skipping existing "+md.FileName, i+1, len(mds))
+			continue
+		}
+		if r.Err != nil && len(p.To) == 0 && !p.JSON {
+			return r.Err
+		}
+	}
+
+	if len(p.To) > 0 {
+		msgs.Amsg(out, "Push summary")
+		summarizePush(p, results, out)
+	}
+	return pushFailures(p, results)
+}
+
+// Push single package to registry. If package version already exists in
+// registry and replace is enabled, existing version will be removed and
+// package will be pushed again. Packages with lint errors are not pushed.
+func pushPackage(p *PushParameters, md PackageMetadata, lintErr error, i, t int, out io.Writer) pushResult {
+	start := time.Now()
+	addr, owner := registryFields(md.Registry)
+	r := pushResult{
+		Metadata: md,
+		Result: Result{
+			Registry: addr,
+			Owner:    owner,
+			Package:  md.Name,
+		},
+	}
+	r.Result.setFile(md.FileName)
+	r.Result.Version = md.Version
+	r.Result.Arch = md.Arch
+
+	if lintErr != nil {
+		r.Err = lintErr
+		r.Result.setExecution(0, start, lintErr)
+		return r
+	}
+
+	status, err := push(*p, md, i, t, out)
+	if errors.Is(err, registry.ErrVersionExists) && p.Replace {
+		r.Replaced, status, err = replace(p, md, i, t, out)
+	}
+	if err == nil && md.Arch == "any" {
+		err = checkArchitectures(p, md)
+	}
+	if err == nil {
+		_, err = pushProvenance(md)
+	}
+	r.Err = err
+	r.Result.setExecution(status, start, err)
+	if err == nil {
+		r.Result.Bytes = fileSize(md.Path)
+	}
+	return r
+}
+
+// Remove existing package version from registry and push package again.
+// Returns true if version was replaced.
+func replace(p *PushParameters, md PackageMetadata, i, t int, out io.Writer) (bool, int, error) {
+	parsed, err := pacman.ParsePackageFile(md.FileName)
+	if err != nil {
+		return false, 0, err
+	}
+	version := parsed.Version
+
+	target := md.Registry.String() + "/" + md.Name + "@" + version
+	if !p.Quick {
+		if !msgs.AskForConfirmation(os.Stdin, out, "Replace "+target) {
+			return false, 0, registry.ErrVersionExists
+		}
+	}
+
+	status, _, err := md.Registry.Remove(&registry.RemoveParameters{
+		Name:    md.Name,
+		Version: version,
+	})
+	if err != nil {
+		return false, status, err
+	}
+	status, err = push(*p, md, i, t, out)
+	return err == nil, status, err
 }
 
-// Export public GPG key, which can be added to gitea/gitlab/github.
-func Export(p *PushParameters) error {
-	ident, err := GnuPGidentity()
+// Ensure architecture independent package is available in all required
+// architecture databases in registry.
+func checkArchitectures(p *PushParameters, md PackageMetadata) error {
+	missing, err := missingArchitectures(p, md, md.Version)
 	if err != nil {
 		return err
 	}
-	gpgemail := strings.Replace(strings.Split(ident, " <")[1], ">", "", 1)
-	cmd := exec.Command("gpg", "--armor", "--export", gpgemail)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	return cmd.Run()
+	if len(missing) > 0 {
+		return fmt.Errorf(
+			"%s is missing in architecture databases: %s",
+			md.FileName, strings.Join(missing, ", "),
+		)
+	}
+	return nil
+}
+
+// Get size of file, 0 if file does not exist.
+func fileSize(p string) int64 {
+	info, err := os.Stat(p)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// Collect metadata for all pushed packages. Packages are pushed to registries
+// provided in arguements, or to each of targets, when they are provided.
+func collectMetadata(p *PushParameters, args []string, out io.Writer) ([]PackageMetadata, error) {
+	pkgs, files := splitPushed(args)
+
+	var paths []string
+	var cachedpkgs []string
+	var err error
+	if len(files) > 0 {
+		msgs.Smsg(out, "Resolving package files", 1, 2)
+		paths, err = resolvePkgFiles(files)
+	} else {
+		msgs.Smsg(out, "Scanning cached packages", 1, 2)
+		cachedpkgs, err = listPkgFilenames(p.Directory)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msgs.Smsg(out, "Preparing package metadata", 2, 2)
+
+	mds, err := prepareTargets(p, pkgs, files, paths, cachedpkgs)
+	if err != nil {
+		return nil, err
+	}
+	return mds, readPkginfos(mds)
+}
+
+// Lint each package file once, before it is pushed to targets. Lint errors
+// are returned by package path.
+func lintMetadata(mds []PackageMetadata, out io.Writer) map[string]error {
+	errs := map[string]error{}
+	linted := map[string]bool{}
+	for _, md := range mds {
+		if linted[md.Path] {
+			continue
+		}
+		linted[md.Path] = true
+		err := lintPackage(out, md.Path)
+		if err != nil {
+			errs[md.Path] = err
+		}
+	}
+	return errs
+}
+
+// Read version and architecture for each package from package metadata.
+func readPkginfos(mds []PackageMetadata) error {
+	infos := map[string]*pacman.Pkginfo{}
+	for i, md := range mds {
+		info, ok := infos[md.Path]
+		if !ok {
+			var err error
+			info, err = pacman.ReadPkginfo(md.Path)
+			if err != nil {
+				return err
+			}
+			infos[md.Path] = info
+		}
+		mds[i].Version = info.Version
+		mds[i].Arch = info.Arch
+	}
+	return nil
+}
+
+// Prepare metadata for packages on each of registry targets.
+func prepareTargets(p *PushParameters, pkgs, files, paths, cachedpkgs []string) ([]PackageMetadata, error) {
+	if len(p.To) == 0 {
+		if len(files) > 0 {
+			return prepareFileMetadata(pkgs, paths, p.Insecure)
+		}
+		return prepareMetadata(p.Directory, cachedpkgs, pkgs, p.Insecure)
+	}
+
+	var mds []PackageMetadata
+	for _, target := range p.To {
+		var targetmds []PackageMetadata
+		var err error
+		if len(files) > 0 {
+			if len(pkgs) > 0 {
+				return nil, errors.New("registry should be provided with --to flag")
+			}
+			targetmds, err = prepareFileMetadata([]string{target}, paths, p.Insecure)
+		} else {
+			var targetpkgs []string
+			for _, pkg := range pkgs {
+				targetpkgs = append(targetpkgs, strings.TrimSuffix(target, "/")+"/"+pkg)
+			}
+			targetmds, err = prepareMetadata(p.Directory, cachedpkgs, targetpkgs, p.Insecure)
+		}
+		if err != nil {
+			return nil, err
+		}
+		mds = append(mds, targetmds...)
+	}
+	return mds, nil
+}
+
+// Result of pushing single package to single registry target.
+type pushResult struct {
+	Result
+	Metadata PackageMetadata
+	Replaced bool
+	Err      error
+}
+
+// Print matrix with push status of each package for each target.
+func summarizePush(p *PushParameters, results []pushResult, out io.Writer) {
+	header := []string{"package"}
+	for _, target := range p.To {
+		reg, err := registry.Open(target, p.Insecure)
+		if err != nil {
+			header = append(header, target)
+			continue
+		}
+		header = append(header, reg.String())
+	}
+
+	var pkgs []string
+	status := map[string]map[string]string{}
+	for _, r := range results {
+		pkg := strings.TrimSuffix(r.Metadata.FileName, ".pkg.tar.zst")
+		target := r.Metadata.Registry.String()
+		if _, ok := status[pkg]; !ok {
+			pkgs = append(pkgs, pkg)
+			status[pkg] = map[string]string{}
+		}
+		switch {
+		case r.Replaced && r.Err == nil:
+			status[pkg][target] = "replaced"
+		case r.Err == nil:
+			status[pkg][target] = "ok"
+		case errors.Is(r.Err, registry.ErrVersionExists):
+			status[pkg][target] = "exists"
+		default:
+			status[pkg][target] = "failed"
+		}
+	}
+
+	var rows [][]string
+	for _, pkg := range pkgs {
+		row := []string{pkg}
+		for _, target := range header[1:] {
+			cell, ok := status[pkg][target]
+			if !ok {
+				cell = "-"
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	msgs.Table(out, header, rows)
+}
+
+// Get joined error for all failed pushes.
+func pushFailures(p *PushParameters, results []pushResult) error {
+	var errs []error
+	for _, r := range results {
+		if r.Err == nil || errors.Is(r.Err, registry.ErrVersionExists) && p.SkipExisting {
+			continue
+		}
+		errs = append(errs, fmt.Errorf(
+			"%s/%s: %w", r.Metadata.Registry.String(),
+			r.Metadata.FileName, r.Err,
+		))
+	}
+	return joinFailures(errs, len(results))
+}
+
+// Create signatures for packages, that do not have them yet.
+func signMissing(mds []PackageMetadata, key string, out io.Writer) error {
+	var unsigned []PackageMetadata
+	for _, md := range mds {
+		_, err := os.Stat(md.Path + ".sig")
+		if errors.Is(err, os.ErrNotExist) {
+			unsigned = append(unsigned, md)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(unsigned) == 0 {
+		return nil
+	}
+
+	msgs.Amsg(out, "Signing packages")
+	for i, md := range unsigned {
+		msgs.Smsg(out, "Signing "+md.FileName, i+1, len(unsigned))
+		err := SignPackage(md.Path, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type PackageMetadata struct {
 	Name     string
 	FileName string
-	Addr     string
-	Owner    string
+	Path     string
+	Version  string
+	Arch     string
+	Registry registry.Registry
+}
+
+// Splits push arguements into registry targets and package files. Arguements
+// pointing to package archives or containing glob patterns are treated as
+// files.
+func splitPushed(args []string) ([]string, []string) {
+	var targets []string
+	var files []string
+	for _, arg := range args {
+		if strings.HasSuffix(arg, ".pkg.tar.zst") ||
+			strings.ContainsAny(arg, "*?[") {
+			files = append(files, arg)
+			continue
+		}
+		targets = append(targets, arg)
+	}
+	return targets, files
+}
+
+// Expand provided file paths and glob patterns to list of package files.
+func resolvePkgFiles(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
+		}
+		var found bool
+		for _, match := range matches {
+			if !strings.HasSuffix(match, ".pkg.tar.zst") {
+				continue
+			}
+			paths = append(paths, match)
+			found = true
+		}
+		if !found {
+			return nil, errors.New("no package files matching: " + pattern)
+		}
+	}
+	return paths, nil
+}
+
+// Collect metadata about package files provided by path, all files are pushed
+// to single registry and owner.
+func prepareFileMetadata(targets, paths []string, insecure bool) ([]PackageMetadata, error) {
+	if len(targets) != 1 {
+		return nil, errors.New("provide single registry/owner to push files")
+	}
+
+	reg, err := registry.Open(targets[0], insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	var mds []PackageMetadata
+	for _, p := range paths {
+		filename := filepath.Base(p)
+		parsed, err := pacman.ParsePackageFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		mds = append(mds, PackageMetadata{
+			Name:     parsed.Name,
+			FileName: filename,
+			Path:     p,
+			Registry: reg,
+		})
+	}
+	return mds, nil
 }
 
 // Collect metadata about packages, ensure all packages could be pushed.
-func prepareMetadata(dir string, filenames, pkgs []string) ([]PackageMetadata, error) {
+func prepareMetadata(dir string, filenames, pkgs []string, insecure bool) ([]PackageMetadata, error) {
 	var mds []PackageMetadata
 	for _, pkg := range pkgs {
-		var (
-			name    string
-			owner   string
-			address string
-		)
-
-		splt := strings.Split(pkg, "/")
-		switch len(splt) {
-		case 1:
+		location, name, err := registry.SplitPackage(pkg)
+		if err != nil {
 			return nil, errors.New("no registry to push: " + pkg)
-		case 2:
-			address = splt[0]
-			name = splt[1]
-		case 3:
-			address = splt[0]
-			owner = splt[1]
-			name = splt[2]
+		}
+		reg, err := registry.Open(location, insecure)
+		if err != nil {
+			return nil, err
 		}
 
 		filenames, err := FilterFilenames(filenames, name)
@@ -126,8 +531,8 @@ func prepareMetadata(dir string, filenames, pkgs []string) ([]PackageMetadata, e
 			mds = append(mds, PackageMetadata{
 				Name:     name,
 				FileName: filename,
-				Addr:     address,
-				Owner:    owner,
+				Path:     path.Join(dir, filename),
+				Registry: reg,
 			})
 		}
 	}
@@ -138,11 +543,11 @@ func prepareMetadata(dir string, filenames, pkgs []string) ([]PackageMetadata, e
 func FilterFilenames(filenames []string, pkg string) ([]string, error) {
 	var rez []string
 	for _, filename := range filenames {
-		pkgsplt := strings.Split(filename, "-")
-		if len(pkgsplt) < 4 {
-			return nil, errors.New("not valid package file name: " + filename)
+		parsed, err := pacman.ParsePackageFile(filename)
+		if err != nil {
+			return nil, err
 		}
-		if !(strings.Join(pkgsplt[:len(pkgsplt)-3], "-") == pkg) {
+		if parsed.Name != pkg {
 			continue
 		}
 		rez = append(rez, filename)
@@ -166,74 +571,33 @@ func listPkgFilenames(dir string) ([]string, error) {
 	return fns, nil
 }
 
-// This function pushes package to registry via http/https.
-func push(pp PushParameters, m PackageMetadata, i, t int) error {
-	pkgpath := path.Join(pp.Directory, m.FileName)
-	packagefile, err := os.Open(pkgpath)
-	if err != nil {
-		return err
+// This function pushes package with signature to registry.
+func push(pp PushParameters, m PackageMetadata, i, t int, out io.Writer) (int, error) {
+	pkgsign, err := os.ReadFile(m.Path + ".sig")
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf(msgs.ErrNoSignature, m.FileName)
 	}
-
-	pkgInfo, err := os.Stat(pkgpath)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	pkgsign, err := os.ReadFile(pkgpath + ".sig")
-	if err != nil {
-		return err
-	}
-
-	protocol := "https"
-	if pp.Insecure {
-		protocol = "http"
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPut,
-		protocol+"://"+path.Join(
-			m.Addr, "api/packages", m.Owner, "arch/push",
-			pp.Distro, base64.RawURLEncoding.EncodeToString(pkgsign),
+	drawer := msgs.Loader(&msgs.LoaderParameters{
+		Current: i,
+		Total:   t,
+		Msg: fmt.Sprintf(
+			"%s/%s", m.Registry.String(),
+			strings.TrimSuffix(m.FileName, ".pkg.tar.zst"),
 		),
-		&ioprogress.Reader{
-			Reader: packagefile,
-			Size:   pkgInfo.Size(),
-			DrawFunc: msgs.Loader(&msgs.LoaderParameters{
-				Current: i,
-				Total:   t,
-				Msg: fmt.Sprintf(
-					"%s/%s", path.Join(m.Addr, m.Owner),
-					strings.TrimSuffix(m.FileName, ".pkg.tar.zst"),
-				),
-				Output: os.Stdout,
-			}),
-		},
-	)
-	if err != nil {
-		return err
+		Output: out,
+	})
+	if drawer == nil {
+		drawer = ioprogress.DrawTerminal(out)
 	}
 
-	login, pass, err := creds.Get(protocol, m.Addr)
-	if err != nil {
-		login, pass, err = creds.Create(protocol, m.Addr, os.Stdin, os.Stdout)
-		if err != nil {
-			return err
-		}
-	}
-
-	req.SetBasicAuth(login, pass)
-
-	var client http.Client
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode != http.StatusCreated {
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return errors.Join(err, errors.New(resp.Status))
-		}
-		return fmt.Errorf("%s %s", resp.Status, string(b))
-	}
-	return nil
+	return m.Registry.Push(&registry.PushParameters{
+		Path:      m.Path,
+		Signature: pkgsign,
+		Distro:    pp.Distro,
+		Progress:  drawer,
+	})
 }
@@ -5,10 +5,13 @@
 package tab
 
 import (
+	"errors"
 	"os"
+	"strings"
 
 	"ion.lc/core/tab/msgs"
 	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
 )
 
 // Parameters that will be used to execute push command.
@@ -19,6 +22,12 @@ type QueryParameters struct {
 	Info []bool `short:"i" long:"info"`
 	// List package files.
 	List []bool `short:"l" long:"list"`
+	// Fetch build provenance of package from registry.
+	Provenance bool `short:"p" long:"provenance"`
+	// Use insecure connection for registry API calls.
+	Insecure bool `long:"insecure"`
+	// Write packages as JSON stream.
+	JSON bool `short:"j" long:"json"`
 }
 
 var QueryHelp = `Query packages
@@ -27,31 +36,39 @@ options:
 	-i, --info     View package information (-ii for backup files)
 	-l, --list     List the files owned by the queried package
 	-o, --outdated List outdated packages
+	-p, --provenance
+	               Fetch build provenance from registry (latest by default)
+	    --insecure Use HTTP protocol for registry API calls
+	-j, --json     Write packages as JSON stream to stdout
 
-usage: tab {-Q --query} [options] <(registry)/(owner)/package(s)>`
+usage: tab {-Q --query} [options] <(registry)/(owner)/package(s)>
+       tab {-Q --query} --provenance <registry/owner/package(@ver-rel)>`
 
 func Query(args []string, prms ...QueryParameters) error {
 	p := getParameters(prms)
 
+	if p.Provenance {
+		return queryProvenance(p, args)
+	}
+
+	if p.JSON {
+		return queryJSON(p, args, os.Stdout)
+	}
+
 	if p.Outdated {
-		err := pacman.SyncList(nil, pacman.SyncParameters{
-			Stdout:  os.Stdout,
-			Stderr:  os.Stderr,
-			Stdin:   os.Stdin,
-			Sudo:    true,
-			Refresh: []bool{true, true},
-		})
-		if err != nil {
+		msgs.Amsg(os.Stdout, "Downloading fresh package databases")
+		outdated, err := CheckOutdated()
+		if err != nil && !errors.Is(err, ErrPartialFailure) {
 			return err
 		}
 
 		msgs.Amsg(os.Stdout, "Outdated packages")
-		return pacman.Query(nil, pacman.QueryParameters{
-			Stdout:  os.Stdout,
-			Stderr:  os.Stderr,
-			Stdin:   os.Stdin,
-			Upgrade: true,
-		})
+		var rows [][]string
+		for _, pkg := range outdated {
+			rows = append(rows, []string{pkg.Name, pkg.CurrentVersion, pkg.NewVersion, pkg.Repository})
+		}
+		msgs.Table(os.Stdout, []string{"package", "installed", "available", "repository"}, rows)
+		return err
 	}
 
 	return pacman.Query(args, pacman.QueryParameters{
@@ -62,3 +79,29 @@ func Query(args []string, prms ...QueryParameters) error {
 		List:   p.List,
 	})
 }
+
+// Print build provenance documents of remote packages.
+func queryProvenance(p *QueryParameters, pkgs []string) error {
+	if len(pkgs) == 0 {
+		return errors.New("specify registry/owner/package to get provenance")
+	}
+	for _, pkg := range pkgs {
+		location, target, err := registry.SplitPackage(pkg)
+		if err != nil {
+			return err
+		}
+		name, version, _ := strings.Cut(target, "@")
+		reg, err := registry.Open(location, p.Insecure)
+		if err != nil {
+			return err
+		}
+		docs, err := fetchProvenance(reg, name, version)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			os.Stdout.Write(doc)
+		}
+	}
+	return nil
+}
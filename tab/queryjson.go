@@ -0,0 +1,211 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"ion.lc/core/tab/alpm"
+	"ion.lc/core/tab/msgs"
+)
+
+// Installed package, that is written in query JSON output. Fields, that
+// require package information, are filled only with --info.
+type QueriedPackage struct {
+	Name          string     `json:"name"`
+	Version       string     `json:"version"`
+	Description   string     `json:"description,omitempty"`
+	Architecture  string     `json:"architecture,omitempty"`
+	URL           string     `json:"url,omitempty"`
+	Licenses      []string   `json:"licenses,omitempty"`
+	Groups        []string   `json:"groups,omitempty"`
+	Provides      []string   `json:"provides,omitempty"`
+	Depends       []string   `json:"depends,omitempty"`
+	OptionalDeps  []string   `json:"optional_deps,omitempty"`
+	RequiredBy    []string   `json:"required_by,omitempty"`
+	OptionalFor   []string   `json:"optional_for,omitempty"`
+	Conflicts     []string   `json:"conflicts,omitempty"`
+	Replaces      []string   `json:"replaces,omitempty"`
+	InstalledSize int64      `json:"installed_size,omitempty"`
+	Packager      string     `json:"packager,omitempty"`
+	BuildDate     *time.Time `json:"build_date,omitempty"`
+	InstallDate   *time.Time `json:"install_date,omitempty"`
+	// Explicit or dependency.
+	InstallReason string   `json:"install_reason,omitempty"`
+	InstallScript bool     `json:"install_script,omitempty"`
+	ValidatedBy   []string `json:"validated_by,omitempty"`
+	Files         []string `json:"files,omitempty"`
+}
+
+// Outdated package, that is written in query JSON output.
+type QueriedUpdate struct {
+	Name           string `json:"name"`
+	CurrentVersion string `json:"current_version"`
+	NewVersion     string `json:"new_version"`
+	Repository     string `json:"repository,omitempty"`
+}
+
+// Write installed packages as JSON stream, all installed packages are
+// written if no packages are provided.
+func queryJSON(p *QueryParameters, pkgs []string, w io.Writer) error {
+	if p.Outdated {
+		return queryOutdatedJSON(w)
+	}
+
+	// Packages are read directly from local database, pacman is not used.
+	local, err := alpm.ReadLocal(systemDBPath(), len(p.List) > 0)
+	if err != nil {
+		return err
+	}
+	installed := map[string]alpm.Package{}
+	for _, pkg := range local {
+		installed[pkg.Name] = pkg
+	}
+	if len(pkgs) == 0 {
+		for _, pkg := range local {
+			pkgs = append(pkgs, pkg.Name)
+		}
+	}
+	for _, pkg := range pkgs {
+		if _, ok := installed[pkg]; !ok {
+			return fmt.Errorf("package '%s' was not found", pkg)
+		}
+	}
+
+	var requiredBy, optionalFor map[string][]string
+	if len(p.Info) > 0 {
+		requiredBy, optionalFor = reverseDepends(local)
+	}
+
+	for _, pkg := range pkgs {
+		qp := QueriedPackage{Name: pkg, Version: installed[pkg].Version}
+		if len(p.Info) > 0 {
+			qp = queriedPackage(installed[pkg], requiredBy, optionalFor)
+		}
+		for _, file := range installed[pkg].Files {
+			qp.Files = append(qp.Files, "/"+file)
+		}
+		err = writeJSON(w, qp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write outdated packages as JSON stream.
+func queryOutdatedJSON(w io.Writer) error {
+	msgs.Amsg(os.Stderr, "Downloading fresh package databases")
+	outdated, failure := CheckOutdated()
+	if failure != nil && !errors.Is(failure, ErrPartialFailure) {
+		return failure
+	}
+	for _, pkg := range outdated {
+		err := writeJSON(w, QueriedUpdate{
+			Name:           pkg.Name,
+			CurrentVersion: pkg.CurrentVersion,
+			NewVersion:     pkg.NewVersion,
+			Repository:     pkg.Repository,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return failure
+}
+
+// Names of validation methods, that pacman shows in package information.
+var validationNames = map[string]string{
+	"none":   "None",
+	"md5":    "MD5 Sum",
+	"sha256": "SHA-256 Sum",
+	"pgp":    "Signature",
+}
+
+// Convert local database entry to typed JSON representation, packages that
+// depend on it are looked up in provided reverse dependencies.
+func queriedPackage(pkg alpm.Package, requiredBy, optionalFor map[string][]string) QueriedPackage {
+	qp := QueriedPackage{
+		Name:          pkg.Name,
+		Version:       pkg.Version,
+		Description:   pkg.Description,
+		Architecture:  pkg.Arch,
+		URL:           pkg.URL,
+		Licenses:      pkg.Licenses,
+		Groups:        pkg.Groups,
+		Provides:      pkg.Provides,
+		Depends:       pkg.Depends,
+		OptionalDeps:  pkg.OptDepends,
+		RequiredBy:    dependents(pkg, requiredBy),
+		OptionalFor:   dependents(pkg, optionalFor),
+		Conflicts:     pkg.Conflicts,
+		Replaces:      pkg.Replaces,
+		InstalledSize: pkg.Size,
+		Packager:      pkg.Packager,
+		InstallReason: pkg.Reason.String(),
+		InstallScript: pkg.Scriptlet,
+	}
+	if !pkg.BuildDate.IsZero() {
+		qp.BuildDate = &pkg.BuildDate
+	}
+	if !pkg.InstallDate.IsZero() {
+		qp.InstallDate = &pkg.InstallDate
+	}
+	for _, validation := range pkg.Validation {
+		name, ok := validationNames[validation]
+		if !ok {
+			name = validation
+		}
+		qp.ValidatedBy = append(qp.ValidatedBy, name)
+	}
+	return qp
+}
+
+// Map dependency names to packages, that require them, and optional
+// dependency names to packages, that can use them.
+func reverseDepends(local []alpm.Package) (map[string][]string, map[string][]string) {
+	requiredBy := map[string][]string{}
+	optionalFor := map[string][]string{}
+	for _, pkg := range local {
+		for _, dep := range pkg.Depends {
+			requiredBy[dependName(dep)] = append(requiredBy[dependName(dep)], pkg.Name)
+		}
+		for _, dep := range pkg.OptDepends {
+			optionalFor[dependName(dep)] = append(optionalFor[dependName(dep)], pkg.Name)
+		}
+	}
+	return requiredBy, optionalFor
+}
+
+// Get sorted packages, that depend on package by its name or on anything it
+// provides.
+func dependents(pkg alpm.Package, reverse map[string][]string) []string {
+	var names []string
+	for _, name := range append([]string{pkg.Name}, pkg.Provides...) {
+		for _, dependent := range reverse[dependName(name)] {
+			if !slices.Contains(names, dependent) {
+				names = append(names, dependent)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get package name from dependency, provision or optional dependency, such
+// as "python>=3.11" or "python: for scripts".
+func dependName(dep string) string {
+	dep, _, _ = strings.Cut(dep, ":")
+	if i := strings.IndexAny(dep, "<>="); i >= 0 {
+		dep = dep[:i]
+	}
+	return strings.TrimSpace(dep)
+}
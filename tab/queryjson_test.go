@@ -0,0 +1,54 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"ion.lc/core/tab/alpm"
+)
+
+func TestQueriedPackage(t *testing.T) {
+	local := []alpm.Package{
+		{
+			Name:        "nano",
+			Version:     "7.2-1",
+			URL:         "https://www.nano-editor.org",
+			Licenses:    []string{"GPL"},
+			Depends:     []string{"glibc", "ncurses"},
+			Provides:    []string{"editor=7.2"},
+			Size:        2621440,
+			BuildDate:   time.Unix(1705140000, 0),
+			Reason:      alpm.ReasonDepend,
+			Validation:  []string{"pgp"},
+			OptDepends:  []string{"sh: for scripts"},
+			InstallDate: time.Unix(1705150000, 0),
+		},
+		{Name: "git", Version: "2.43-1", Depends: []string{"editor>=7"}},
+		{Name: "tool", Version: "1-1", Depends: []string{"nano"}},
+		{Name: "mc", Version: "4.8-1", OptDepends: []string{"nano: internal editor"}},
+	}
+
+	requiredBy, optionalFor := reverseDepends(local)
+	qp := queriedPackage(local[0], requiredBy, optionalFor)
+	assert.Equal(t, []string{"glibc", "ncurses"}, qp.Depends)
+	assert.Equal(t, []string{"git", "tool"}, qp.RequiredBy)
+	assert.Equal(t, []string{"mc"}, qp.OptionalFor)
+	assert.Zero(t, qp.Groups)
+	assert.Equal(t, int64(2621440), qp.InstalledSize)
+	assert.NotZero(t, qp.BuildDate)
+	assert.Equal(t, int64(1705140000), qp.BuildDate.Unix())
+	assert.Equal(t, "dependency", qp.InstallReason)
+	assert.Equal(t, []string{"Signature"}, qp.ValidatedBy)
+	assert.False(t, qp.InstallScript)
+
+	qp = queriedPackage(local[1], requiredBy, optionalFor)
+	assert.Zero(t, qp.RequiredBy)
+	assert.Zero(t, qp.InstallDate)
+	assert.Equal(t, "explicit", qp.InstallReason)
+}
@@ -8,14 +8,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path"
+	"slices"
 	"strings"
+	"time"
 
-	"ion.lc/core/tab/creds"
+	"github.com/fatih/color"
 	"ion.lc/core/tab/msgs"
 	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/registry"
 )
 
 type RemoveParameters struct {
@@ -29,6 +31,34 @@ type RemoveParameters struct {
 	Cascade bool `short:"s" long:"cascade"`
 	// Use insecure connection for remote deletions.
 	Insecure bool `short:"i" long:"insecure"`
+	// Write remote removal results as JSON stream.
+	JSON bool `short:"j" long:"json"`
+	// Remove old package versions from registry by retention policy.
+	Prune bool `long:"prune"`
+	// Amount of newest versions kept by prune (default 1) and cache pruning
+	// (default 3).
+	Keep *int `short:"k" long:"keep"`
+	// Prune only versions older than provided age, such as 90d or 12h.
+	OlderThan string `short:"o" long:"older-than"`
+	// Versions, that are never pruned, in package@version format, globs
+	// and comparisons can be used.
+	Protect []string `short:"t" long:"protect"`
+	// Only print remote deletion plan without removing anything.
+	Print bool `short:"p" long:"print"`
+	// Do not ask for confirmation of remote deletions and orphan selection.
+	Quick bool `short:"q" long:"quick"`
+	// Remove remote versions only from provided distribution.
+	Distro string `short:"d" long:"distro"`
+	// Remove remote versions only from provided architecture.
+	Arch string `short:"a" long:"arch"`
+	// Remove packages installed as dependencies, that are not required.
+	Orphans bool `long:"orphans"`
+	// Remove old package versions from package cache.
+	Cache bool `long:"cache"`
+	// Package cache directory.
+	Cachedir string `long:"cachedir" default:"/var/cache/pacman/pkg"`
+	// Prune only packages, that are not installed.
+	Uninstalled bool `short:"u" long:"uninstalled"`
 }
 
 var RemoveHelp = `Remove packages
@@ -39,24 +69,67 @@ options:
 	-f, --nocfgs   Leave package configs in the system (removed by default)
 	-s, --cascade  Remove packages and all packages that depend on them
 	-i, --insecure Use HTTP protocol for API calls (remote delete)
+	-j, --json     Write remote removal results as JSON stream to stdout
+	-p, --print    Only print remote or cache deletion plan (dry run)
+	-q, --quick    Do not ask for confirmation of remote deletions and orphans
+	-d, --distro   Remove remote versions only from distribution (default all)
+	-a, --arch     Remove remote versions only from architecture (default all),
+	               gitea registries require --distro together with --arch
+	    --prune    Remove old versions from registry/owner by retention policy
+	-k, --keep <n> Amount of newest versions kept by prune (default 1) and
+	               cache (default 3)
+	-o, --older-than <age>
+	               Prune only versions older than age, such as 90d or 12h
+	-t, --protect <pkg@ver>
+	               Never prune matching versions, can be used multiple times
+	    --orphans  Remove dependencies, that are not required by any package
+	    --cache    Remove old package versions with signatures from cache
+	    --cachedir <dir>
+	               Package cache directory (default /var/cache/pacman/pkg)
+	-u, --uninstalled
+	               Prune only packages, that are not installed
 
-usage: tab {-R --remove} [options] <(registry)/(owner)/package(s)(@ver-rel)>`
+usage: tab {-R --remove} [options] <(registry)/(owner)/package(s)(@ver-rel)>
+       tab {-R --remove} --prune [options] <registry/owner> [package(s)]
+       tab {-R --remove} --orphans [options]
+       tab {-R --remove} --cache [options]
+
+Remote versions can be globs or comparisons, several packages of one owner
+can be separated by commas: registry/owner/pkg@*,other@<1.2-1`
 
 func Remove(args []string, prms ...RemoveParameters) error {
 	p := getParameters(prms)
 
+	out := humanOutput(p.JSON)
+
+	if p.Prune {
+		return prune(p, args, out)
+	}
+
+	if p.Orphans {
+		return recordTransaction("remove", []string{"--orphans"}, func() error {
+			return removeOrphans(p, out)
+		})
+	}
+
+	if p.Cache {
+		return pruneCache(p, out)
+	}
+
 	local, remote := splitRemoved(args)
 
 	if len(local) > 0 {
-		err := pacman.RemoveList(local, pacman.RemoveParameters{
-			Sudo:        true,
-			NoConfirm:   !p.Confirm,
-			Recursive:   !p.Norecursive,
-			WithConfigs: !p.Nocfgs,
-			Cascade:     p.Cascade,
-			Stdout:      os.Stdout,
-			Stderr:      os.Stderr,
-			Stdin:       os.Stdin,
+		err := recordTransaction("remove", local, func() error {
+			return pacman.RemoveList(local, pacman.RemoveParameters{
+				Sudo:        true,
+				NoConfirm:   !p.Confirm,
+				Recursive:   !p.Norecursive,
+				WithConfigs: !p.Nocfgs,
+				Cascade:     p.Cascade,
+				Stdout:      out,
+				Stderr:      os.Stderr,
+				Stdin:       os.Stdin,
+			})
 		})
 		if err != nil {
 			return err
@@ -64,19 +137,36 @@ func Remove(args []string, prms ...RemoveParameters) error {
 	}
 
 	if len(remote) > 0 {
-		msgs.Amsg(os.Stdout, "Removing remote packages")
-		for i, pkg := range remote {
-			msgs.Smsg(os.Stdout, "Removing "+pkg, i+1, len(remote))
-			err := rmRemote(p, pkg)
-			if err != nil {
-				return err
-			}
+		msgs.Amsg(out, "Listing remote package versions")
+		plan, err := planRemoval(p, remote)
+		if err != nil {
+			return err
 		}
+
+		proceed, err := reviewRemoval(p, plan, out)
+		if err != nil || !proceed {
+			return err
+		}
+
+		msgs.Amsg(out, "Removing remote packages")
+		return removeRemote(p, plan, out)
 	}
 
 	return nil
 }
 
+// Amount of newest versions to keep, provided default is used when --keep
+// is not set.
+func (p *RemoveParameters) keep(def int) (int, error) {
+	if p.Keep == nil {
+		return def, nil
+	}
+	if *p.Keep < 0 {
+		return 0, errors.New("amount of kept versions can not be negative")
+	}
+	return *p.Keep, nil
+}
+
 // Splits packages that will be removed locally and on remote.
 func splitRemoved(pkgs []string) ([]string, []string) {
 	var local []string
@@ -91,70 +181,289 @@ func splitRemoved(pkgs []string) ([]string, []string) {
 	return local, remote
 }
 
-// Get remote, owner, target and version from input arguement.
-func splitPkg(pkg string) (string, string, string, string, error) {
-	splt := strings.Split(pkg, "/")
-	if len(splt) == 2 {
-		pkg, ver, err := splitVer(splt[1])
-		return splt[0], ``, pkg, ver, err
-	}
-	pkg, ver, err := splitVer(splt[2])
-	return splt[0], splt[1], pkg, ver, err
+// Package version, that should be removed from registry.
+type remoteRemoval struct {
+	Registry registry.Registry
+	Name     string
+	Version  string
+	// Total size of version files, -1 if unknown.
+	Size int64
+	// Removal deletes last remaining version of package in registry.
+	Last bool
 }
 
-func splitVer(pkg string) (string, string, error) {
-	splt := strings.Split(pkg, "@")
-	if len(splt) != 2 {
-		return "", "", fmt.Errorf("unable to eject version from %s", pkg)
+// Resolve remote removal arguements to list of package versions. Arguement
+// can contain several comma separated packages of one owner, each with exact
+// version, glob or comparison, such as pkg@*, pkg@1.2.* or pkg@<1.2.
+func planRemoval(p *RemoveParameters, args []string) ([]remoteRemoval, error) {
+	var plan []remoteRemoval
+	for _, arg := range args {
+		location, targets, err := registry.SplitPackage(arg)
+		if err != nil {
+			return nil, err
+		}
+		reg, err := registry.Open(location, p.Insecure)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, target := range strings.Split(targets, ",") {
+			name, selector, err := splitVer(target)
+			if err != nil {
+				return nil, err
+			}
+
+			if !isVersionPattern(selector) {
+				plan = append(plan, remoteRemoval{Registry: reg, Name: name, Version: selector})
+				continue
+			}
+
+			versions, err := matchVersions(reg, name, selector)
+			if err != nil {
+				return nil, err
+			}
+			if len(versions) == 0 {
+				return nil, fmt.Errorf("no versions of %s/%s match %s", reg, name, selector)
+			}
+			for _, version := range versions {
+				plan = append(plan, remoteRemoval{Registry: reg, Name: name, Version: version})
+			}
+		}
 	}
-	return splt[0], splt[1], nil
+	return plan, nil
 }
 
-// Function that will be used to remove remote package.
-func rmRemote(p *RemoveParameters, pkg string) error {
-	remote, owner, target, version, err := splitPkg(pkg)
+// Check whether version selector is glob or comparison instead of exact
+// version.
+func isVersionPattern(selector string) bool {
+	return strings.ContainsAny(selector, "*?[<>=")
+}
+
+// List versions of package published in registry, that match selector.
+// Versions are sorted from oldest to newest.
+func matchVersions(reg registry.Registry, name, selector string) ([]string, error) {
+	pkgs, err := reg.List(name)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var versions []string
+	for _, pkg := range pkgs {
+		ok, err := matchVersion(pkg.Version, selector)
+		if err != nil {
+			return nil, err
+		}
+		if ok && !slices.Contains(versions, pkg.Version) {
+			versions = append(versions, pkg.Version)
+		}
 	}
+	slices.SortFunc(versions, pacman.Vercmp)
+	return versions, nil
+}
 
-	protocol := "https"
-	if p.Insecure {
-		protocol = "http"
+// Check whether version matches selector. Comparisons use pacman version
+// ordering, other selectors are treated as globs.
+func matchVersion(version, selector string) (bool, error) {
+	for _, op := range []string{"<=", ">=", "<", ">", "="} {
+		if !strings.HasPrefix(selector, op) {
+			continue
+		}
+		cmp := pacman.Vercmp(version, strings.TrimPrefix(selector, op))
+		switch op {
+		case "<=":
+			return cmp <= 0, nil
+		case ">=":
+			return cmp >= 0, nil
+		case "<":
+			return cmp < 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp == 0, nil
 	}
+	return path.Match(selector, version)
+}
 
-	req, err := http.NewRequest(
-		http.MethodDelete,
-		protocol+"://"+path.Join(
-			remote, "api/packages", owner,
-			"arch/remove", target, version,
-		),
-		nil,
-	)
+// Show deletion plan and ask user to confirm it, returns false if removal
+// should not be executed.
+func reviewRemoval(p *RemoveParameters, plan []remoteRemoval, out io.Writer) (bool, error) {
+	err := previewRemoval(p, plan)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	login, pass, err := creds.Get(protocol, remote)
-	if err != nil {
-		login, pass, err = creds.Create(protocol, remote, os.Stdin, os.Stdout)
+	msgs.Amsg(out, "Deletion plan")
+	printRemovalPlan(out, plan)
+	if p.Print || p.Quick {
+		return !p.Print, nil
+	}
+
+	if !msgs.AskForConfirmation(os.Stdin, out, fmt.Sprintf("Remove %d package version(s) from registry", len(plan))) {
+		return false, errors.New("remote removal cancelled")
+	}
+
+	// Removal of last versions is confirmed by typing package name.
+	var confirmed []string
+	for _, rm := range plan {
+		if !rm.Last || slices.Contains(confirmed, rm.Name) {
+			continue
+		}
+		msg := fmt.Sprintf(
+			"%s: all versions of %s will be removed from %s, type package name to confirm: ",
+			color.New(color.Bold, color.FgYellow).Sprint("warning"), rm.Name, rm.Registry,
+		)
+		name, err := msgs.Inp(msg, out, os.Stdin, false)
 		if err != nil {
-			return err
+			return false, err
 		}
+		if strings.TrimSpace(name) != rm.Name {
+			return false, errors.New("package name does not match, remote removal cancelled")
+		}
+		confirmed = append(confirmed, rm.Name)
 	}
+	return true, nil
+}
 
-	req.SetBasicAuth(login, pass)
+// Fill sizes of removed versions and mark removals of last remaining package
+// versions in registry. Removals scoped to distribution or architecture are
+// never marked as last.
+func previewRemoval(p *RemoveParameters, plan []remoteRemoval) error {
+	published := map[string][]string{}
+	planned := map[string][]string{}
+	for i := range plan {
+		rm := &plan[i]
+		rm.Size = -1
+		files, err := rm.Registry.Files(rm.Name, rm.Version)
+		if err == nil {
+			rm.Size = 0
+			for _, f := range files {
+				if p.Arch == `` || strings.HasSuffix(f.Name, "-"+p.Arch+".pkg.tar.zst") {
+					rm.Size += f.Size
+				}
+			}
+		}
 
-	var client http.Client
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+		key := rm.Registry.String() + "/" + rm.Name
+		if _, ok := published[key]; !ok {
+			pkgs, err := rm.Registry.List(rm.Name)
+			if err != nil {
+				return err
+			}
+			published[key] = []string{}
+			for _, pkg := range pkgs {
+				published[key] = append(published[key], pkg.Version)
+			}
+		}
+		planned[key] = append(planned[key], rm.Version)
 	}
-	if resp.StatusCode != http.StatusNoContent {
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return errors.Join(err, errors.New(resp.Status))
+
+	for i := range plan {
+		key := plan[i].Registry.String() + "/" + plan[i].Name
+		plan[i].Last = p.Distro == `` && p.Arch == ``
+		for _, version := range published[key] {
+			if !slices.Contains(planned[key], version) {
+				plan[i].Last = false
+			}
 		}
-		return fmt.Errorf("%s %s", resp.Status, string(b))
 	}
 	return nil
 }
+
+// Print package versions, that will be removed from registries.
+func printRemovalPlan(out io.Writer, plan []remoteRemoval) {
+	var rows [][]string
+	for _, rm := range plan {
+		addr, owner := registryFields(rm.Registry)
+		if owner == `` {
+			owner = "-"
+		}
+		size := "?"
+		if rm.Size >= 0 {
+			size = formatSize(rm.Size)
+		}
+		version := rm.Version
+		if rm.Last {
+			version += " (last)"
+		}
+		rows = append(rows, []string{addr, owner, rm.Name, version, size})
+	}
+	msgs.Table(out, []string{"registry", "owner", "package", "version", "size"}, rows)
+}
+
+// Format size in bytes to human readable format.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// Remove planned package versions from registries, each removal is reported
+// separately.
+func removeRemote(p *RemoveParameters, plan []remoteRemoval, out io.Writer) error {
+	var errs []error
+	var rows [][]string
+	for i, rm := range plan {
+		pkg := rm.Registry.String() + "/" + rm.Name + "@" + rm.Version
+		msgs.Smsg(out, "Removing "+pkg, i+1, len(plan))
+		r, err := rmRemote(p, rm)
+		if p.JSON {
+			err := writeResult(os.Stdout, r)
+			if err != nil {
+				return err
+			}
+		}
+		status := "removed"
+		if err != nil {
+			status = "failed"
+			errs = append(errs, fmt.Errorf("%s: %w", pkg, err))
+		}
+		targets := strings.Join(r.Targets, " ")
+		if targets == `` {
+			targets = "-"
+		}
+		rows = append(rows, []string{rm.Registry.String(), rm.Name, rm.Version, status, targets})
+	}
+
+	msgs.Amsg(out, "Removal summary")
+	msgs.Table(out, []string{"registry", "package", "version", "status", "removed from"}, rows)
+	return joinFailures(errs, len(plan))
+}
+
+func splitVer(pkg string) (string, string, error) {
+	splt := strings.Split(pkg, "@")
+	if len(splt) != 2 || splt[0] == `` || splt[1] == `` {
+		return "", "", fmt.Errorf("unable to eject version from %s", pkg)
+	}
+	return splt[0], splt[1], nil
+}
+
+// Function that will be used to remove remote package version.
+func rmRemote(p *RemoveParameters, rm remoteRemoval) (Result, error) {
+	start := time.Now()
+
+	r := Result{
+		Package: rm.Name,
+		Version: rm.Version,
+		Distro:  p.Distro,
+		Arch:    p.Arch,
+	}
+	r.Registry, r.Owner = registryFields(rm.Registry)
+
+	status, targets, err := rm.Registry.Remove(&registry.RemoveParameters{
+		Name:    rm.Name,
+		Version: rm.Version,
+		Distro:  p.Distro,
+		Arch:    p.Arch,
+	})
+	for _, t := range targets {
+		r.Targets = append(r.Targets, t.String())
+	}
+	r.setExecution(status, start, err)
+	return r, err
+}
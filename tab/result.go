@@ -0,0 +1,102 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"ion.lc/core/tab/pacman"
+)
+
+// Returned together with failures, when only part of registry operations
+// succeeded. Program should exit with code 2 in that case.
+var ErrPartialFailure = errors.New("some of operations failed")
+
+// Result of single registry operation, that is written in JSON output.
+type Result struct {
+	Registry string `json:"registry"`
+	Owner    string `json:"owner"`
+	Package  string `json:"package"`
+	Version  string `json:"version"`
+	Distro   string `json:"distro,omitempty"`
+	Arch     string `json:"arch"`
+	File     string `json:"file"`
+	// Distribution and architecture combinations in distro/arch format,
+	// that were affected by removal.
+	Targets []string `json:"targets,omitempty"`
+	// HTTP status of last request, 0 if request was not sent.
+	Status int `json:"status"`
+	// Amount of transferred package bytes.
+	Bytes int64 `json:"bytes"`
+	// Duration of operation in seconds.
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Fill version and architecture of result from package file name.
+func (r *Result) setFile(filename string) {
+	r.File = filename
+	parsed, err := pacman.ParsePackageFile(filename)
+	if err != nil {
+		return
+	}
+	r.Package = parsed.Name
+	r.Version = parsed.Version
+	r.Arch = parsed.Arch
+}
+
+// Fill result fields related to operation execution.
+func (r *Result) setExecution(status int, start time.Time, err error) {
+	r.Status = status
+	r.Duration = time.Since(start).Seconds()
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// Write result as single JSON line. Operations should be stopped if result
+// can not be written, since they would not be reported.
+func writeResult(w io.Writer, r Result) error {
+	err := writeJSON(w, r)
+	if err != nil {
+		return errors.New("unable to write result: " + err.Error())
+	}
+	return nil
+}
+
+// Write value as single line of JSON stream.
+func writeJSON(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Get writer for human readable messages, when JSON output is enabled they
+// are written to stderr.
+func humanOutput(json bool) io.Writer {
+	if json {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Join errors from multiple operations. Error is wrapped with partial failure
+// if some of operations succeeded.
+func joinFailures(errs []error, total int) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) < total {
+		return errors.Join(append([]error{ErrPartialFailure}, errs...)...)
+	}
+	return errors.Join(errs...)
+}
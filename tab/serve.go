@@ -0,0 +1,49 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"fmt"
+
+	"ion.lc/core/tab/server"
+)
+
+type ServeParameters struct {
+	// Address server is listening on.
+	Addr string `short:"a" long:"addr" default:":8080"`
+	// Directory, where packages and databases are stored.
+	Dir string `short:"d" long:"dir" default:"/var/lib/tab"`
+	// File with users allowed to push and remove packages.
+	Users string `short:"u" long:"users" default:"/etc/tab/users"`
+	// GnuPG home directory with keys of trusted signers.
+	Keyring string `short:"k" long:"keyring"`
+}
+
+var ServeHelp = `Run self-hosted package registry
+
+options:
+	-a, --addr    Address server is listening on (default :8080)
+	-d, --dir     Directory for packages and databases (default /var/lib/tab)
+	-u, --users   Users file in login:bcrypt(password) format (default /etc/tab/users)
+	-k, --keyring GnuPG home with keys of trusted signers (default user keyring)
+
+usage: tab --serve [options]`
+
+func Serve(args []string, prms ...ServeParameters) error {
+	p := getParameters(prms)
+
+	users, err := server.ReadUsers(p.Users)
+	if err != nil {
+		return err
+	}
+
+	s := &server.Server{
+		Root:      p.Dir,
+		Users:     users,
+		GnuPGHome: p.Keyring,
+	}
+	fmt.Printf("Serving registry from %s on %s\n", p.Dir, p.Addr)
+	return s.ListenAndServe(p.Addr)
+}
@@ -0,0 +1,89 @@
+// Use of this code is governed by GNU General Public License.
+// Official web page: https://ion.lc/core/tab
+// Contact email: help@ion.lc
+
+package tab
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"ion.lc/core/tab/msgs"
+	"ion.lc/core/tab/pacman"
+	"ion.lc/core/tab/process"
+)
+
+// Create detached signature for package file and store it next to package.
+// Key is optional, if empty first ultimate GnuPG identity is used. Packager
+// in package metadata should match signing identity.
+func SignPackage(pkgpath, key string) error {
+	ident, err := GnuPGkeyIdentity(key)
+	if err != nil {
+		return err
+	}
+
+	info, err := pacman.ReadPkginfo(pkgpath)
+	if err != nil {
+		return err
+	}
+	if info.Packager != ident {
+		return fmt.Errorf(
+			msgs.ErrSignerPackagerMissmatch,
+			filepath.Base(pkgpath), info.Packager, ident,
+		)
+	}
+
+	tmp, err := os.MkdirTemp(``, "tab-sign")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	tmpsig := filepath.Join(tmp, filepath.Base(pkgpath)+".sig")
+
+	// Without key package is signed with validated identity, not with gpg
+	// default key, which can be different.
+	if key == `` {
+		key = ident
+	}
+	gpgargs := []string{"--detach-sign", "--use-agent", "--no-armor", "--local-user", key}
+	gpgargs = append(gpgargs, "--output", tmpsig, pkgpath)
+
+	var b bytes.Buffer
+	cmd := exec.Command("gpg", gpgargs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err = cmd.Run()
+	if err != nil {
+		return errors.New("unable to sign package: " + b.String())
+	}
+
+	sigpath := pkgpath + ".sig"
+	err = os.Rename(tmpsig, sigpath)
+	if err == nil {
+		return nil
+	}
+	return call(process.Command(&process.Params{
+		Sudo:    true,
+		Command: "mv",
+		Args:    []string{tmpsig, sigpath},
+	}))
+}
+
+// Verify detached signature stored next to package file with GnuPG.
+func VerifySignature(pkgpath string) error {
+	var b bytes.Buffer
+	cmd := exec.Command("gpg", "--batch", "--verify", pkgpath+".sig", pkgpath)
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err := cmd.Run()
+	if err != nil {
+		return errors.New("unable to verify signature: " + strings.TrimSpace(b.String()))
+	}
+	return nil
+}
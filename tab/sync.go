@@ -12,6 +12,7 @@ import (
 
 	"ion.lc/core/tab/pacman"
 	"ion.lc/core/tab/process"
+	"ion.lc/core/tab/registry"
 )
 
 type SyncParameters struct {
@@ -42,6 +43,12 @@ usage: tab {-S --sync} [options] <(registry)/(owner)/package(s)>`
 func Sync(args []string, prms ...SyncParameters) error {
 	p := getParameters(prms)
 
+	return recordTransaction("sync", args, func() error {
+		return syncPackages(p, args)
+	})
+}
+
+func syncPackages(p *SyncParameters, args []string) error {
 	var err error
 	var conf *string
 	var pkgs []string
@@ -82,44 +89,49 @@ func Sync(args []string, prms ...SyncParameters) error {
 	return nil
 }
 
+// Distribution and architecture of databases added to pacman.conf.
+const (
+	syncDistro = "archlinux"
+	syncArch   = "x86_64"
+)
+
 // Iterate over packages, check wether package database is present, if not
 // add new database to pacman.conf. Return previous version of pacman.conf.
 func addMissingDatabases(pkgs []string, insecure bool) (*string, error) {
-	protocol := "https"
-	if insecure {
-		protocol = "http"
-	}
 	f, err := os.ReadFile("/etc/pacman.conf")
 	if err != nil {
 		return nil, err
 	}
 	conf := string(f)
+	added := map[string]bool{}
 	for _, pkg := range pkgs {
-		splt := strings.Split(pkg, "/")
-		switch len(splt) {
-		case 2:
-			if strings.Contains(conf, fmt.Sprintf("[%s]", splt[0])) {
-				continue
-			}
-			addConfDatabase(protocol, splt[0], splt[0], "")
-		case 3:
-			if strings.Contains(conf, fmt.Sprintf("[%s.%s]", splt[1], splt[0])) {
-				continue
-			}
-			addConfDatabase(protocol, splt[1]+"."+splt[0], splt[0], "/"+splt[1])
+		if !strings.Contains(pkg, "/") {
+			continue
 		}
+		location, _, err := registry.SplitPackage(pkg)
+		if err != nil {
+			return nil, err
+		}
+		reg, err := registry.Open(location, insecure)
+		if err != nil {
+			return nil, err
+		}
+		database := reg.DatabaseName()
+		if added[database] || strings.Contains(conf, fmt.Sprintf("[%s]", database)) {
+			continue
+		}
+		err = addConfDatabase(database, reg.DatabaseServer(syncDistro, syncArch))
+		if err != nil {
+			return nil, err
+		}
+		added[database] = true
 	}
 	return &conf, nil
 }
 
 // Simple function to add database to pacman.conf.
-func addConfDatabase(protocol, database, domain, owner string) error {
-	const (
-		confroot = "\n[%s]\nServer = %s://%s/api/packages/%s/arch/%s/%s\n"
-		os       = "archlinux"
-		arch     = "x86_64"
-	)
-	tmpl := fmt.Sprintf(confroot, database, protocol, domain, owner, os, arch)
+func addConfDatabase(database, server string) error {
+	tmpl := fmt.Sprintf("\n[%s]\nServer = %s\n", database, server)
 	command := "cat <<EOF >> /etc/pacman.conf" + tmpl + "EOF"
 	return call(process.Command(&process.Params{
 		Sudo:    true,
@@ -132,15 +144,17 @@ func addConfDatabase(protocol, database, domain, owner string) error {
 func formatPackages(pkgs []string) []string {
 	var out []string
 	for _, pkg := range pkgs {
-		splt := strings.Split(pkg, "/")
-		switch len(splt) {
-		case 1:
+		location, name, err := registry.SplitPackage(pkg)
+		if err != nil {
+			out = append(out, pkg)
+			continue
+		}
+		reg, err := registry.Open(location, false)
+		if err != nil {
 			out = append(out, pkg)
-		case 2:
-			out = append(out, splt[0]+"/"+splt[1])
-		case 3:
-			out = append(out, splt[1]+"."+splt[0]+"/"+splt[2])
+			continue
 		}
+		out = append(out, reg.DatabaseName()+"/"+name)
 	}
 	return out
 }